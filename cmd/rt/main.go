@@ -14,8 +14,26 @@ func main() {
 	c.Args = os.Args[1:]
 	c.Commands = map[string]cli.CommandFactory{
 		"publish": commands.PublishCommandFactory,
+		"pack":    commands.PackCommandFactory,
 		"gha":     commands.GHACommandFactory,
 		"login":   commands.LoginCommandFactory,
+		"serve":   commands.ServeCommandFactory,
+		"mirror":  commands.MirrorCommandFactory,
+		"verify":  commands.VerifyCommandFactory,
+		"show":    commands.ShowCommandFactory,
+		"sbom":    commands.SBOMCommandFactory,
+		"docs":    commands.DocsCommandFactory,
+		"lint":    commands.LintCommandFactory,
+		"init":    commands.InitCommandFactory,
+
+		"ci publish": commands.CIPublishCommandFactory,
+
+		"consumers find":   commands.ConsumersFindCommandFactory,
+		"consumers update": commands.ConsumersUpdateCommandFactory,
+
+		"token create": commands.TokenCreateCommandFactory,
+		"token list":   commands.TokenListCommandFactory,
+		"token revoke": commands.TokenRevokeCommandFactory,
 	}
 
 	c.HiddenCommands = []string{"gha"}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"context"
+)
+
+// Account describes the user or service account that a token authenticates as.
+type Account struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// Whoami returns the account associated with the client's token. It returns
+// ErrUnauthorized if the token is not valid.
+func (c *Client) Whoami(ctx context.Context) (*Account, error) {
+	var account Account
+	if err := c.do(ctx, "GET", "/api/whoami", nil, &account); err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
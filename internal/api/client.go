@@ -0,0 +1,169 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/registry-tools/rt-cli/version"
+)
+
+// ErrUnauthorized is returned when the API rejects the credentials used by the
+// client.
+var ErrUnauthorized = errors.New("the registry rejected the token. Check your credentials or re-run `rt login`")
+
+// Client makes authenticated requests to Registry Tools API endpoints that are
+// not covered by the SDK.
+type Client struct {
+	// BaseURL is the root of the Registry Tools API. Request paths are
+	// resolved relative to it.
+	BaseURL *url.URL
+	Token   string
+
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the API served at the root of hostname.
+func NewClient(hostname, token string) *Client {
+	return &Client{
+		BaseURL: &url.URL{Scheme: "https", Host: hostname, Path: "/"},
+		Token:   token,
+	}
+}
+
+// Error is an error response returned by the API.
+type Error struct {
+	StatusCode int
+	Title      string
+	Detail     string
+}
+
+func (e *Error) Error() string {
+	msg := e.Title
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Detail != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Detail)
+	}
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, msg)
+}
+
+// IsNotFound reports whether err is an API error with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+type document struct {
+	Data any `json:"data"`
+}
+
+// responseDocument is a document returned by the API. Links.Next is the
+// link to the next page of a paginated response, and is empty on the last
+// page.
+type responseDocument struct {
+	Data  any `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+type errorDocument struct {
+	Errors []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// do performs a request against the API. The path may include a query
+// string. If body is non-nil it is sent as the
+// "data" member of a JSON document, and if out is non-nil the "data" member of
+// the response is decoded into it.
+func (c *Client) do(ctx context.Context, method, path string, body any, out any) error {
+	_, err := c.doURL(ctx, method, c.endpoint(path), body, out)
+	return err
+}
+
+// endpoint resolves a path, which may include a query string, against the
+// base URL.
+func (c *Client) endpoint(path string) *url.URL {
+	path, query, _ := strings.Cut(path, "?")
+	endpoint := c.BaseURL.JoinPath(strings.Split(strings.TrimPrefix(path, "/"), "/")...)
+	endpoint.RawQuery = query
+	return endpoint
+}
+
+// doURL is like do, but requests endpoint and returns the link to the next
+// page of the response, if there is one.
+func (c *Client) doURL(ctx context.Context, method string, endpoint *url.URL, body any, out any) (string, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(document{Data: body})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "rt-cli/"+version.Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	log.Printf("[DEBUG] %s %s", method, endpoint)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return "", ErrUnauthorized
+	}
+
+	if res.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: res.StatusCode}
+
+		var doc errorDocument
+		if err := json.NewDecoder(res.Body).Decode(&doc); err == nil && len(doc.Errors) > 0 {
+			apiErr.Title = doc.Errors[0].Title
+			apiErr.Detail = doc.Errors[0].Detail
+		}
+		return "", apiErr
+	}
+
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return "", nil
+	}
+
+	doc := responseDocument{Data: out}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return doc.Links.Next, nil
+}
@@ -0,0 +1,77 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+)
+
+func newTestServer(t *testing.T, mux *http.ServeMux) *api.Client {
+	t.Helper()
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %s", err)
+	}
+
+	client := api.NewClient(serverURL.Host, "test-token")
+	client.HTTPClient = server.Client()
+	return client
+}
+
+func TestWhoami(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/whoami", func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer test-token" {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(`{"data":{"id":"sa-1","type":"service-account","name":"bootstrap"}}`))
+	})
+
+	client := newTestServer(t, mux)
+
+	account, err := client.Whoami(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if account.ID != "sa-1" || account.Name != "bootstrap" {
+		t.Errorf("unexpected account %+v", account)
+	}
+
+	client.Token = "wrong-token"
+	if _, err := client.Whoami(context.Background()); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/whoami", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusNotFound)
+		_, _ = res.Write([]byte(`{"errors":[{"title":"Not Found","detail":"no such thing"}]}`))
+	})
+
+	client := newTestServer(t, mux)
+
+	_, err := client.Whoami(context.Background())
+	if !api.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	if err.Error() != "API error (404): Not Found: no such thing" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+}
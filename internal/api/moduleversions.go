@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ModuleVersion is a published version of a module.
+type ModuleVersion struct {
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	System    string    `json:"system"`
+	Version   string    `json:"version"`
+	Yanked    bool      `json:"yanked"`
+	CreatedAt time.Time `json:"created-at"`
+
+	// SourceRepository and SourceCommit identify the git commit that the
+	// version was published from, if it was recorded.
+	SourceRepository string `json:"source-repository,omitempty"`
+	SourceCommit     string `json:"source-commit,omitempty"`
+
+	// ArchiveSHA256 is the hex encoded digest of the published archive, if
+	// it was recorded.
+	ArchiveSHA256 string `json:"archive-sha256,omitempty"`
+}
+
+type yankModuleVersionRequest struct {
+	Yanked       bool   `json:"yanked"`
+	YankedReason string `json:"yanked-reason,omitempty"`
+}
+
+// ListModuleVersions returns the published versions of a module, including
+// yanked versions.
+func (c *Client) ListModuleVersions(ctx context.Context, namespace, name, system string) ([]ModuleVersion, error) {
+	if namespace == "" || name == "" || system == "" {
+		return nil, errors.New("a namespace, name and system are required")
+	}
+
+	query := url.Values{
+		"filter[namespace]": {namespace},
+		"filter[name]":      {name},
+		"filter[system]":    {system},
+	}
+
+	// Registries may page the versions, so the next links are followed until
+	// the last page
+	var versions []ModuleVersion
+	endpoint := c.endpoint("/api/terraform-module-versions?" + query.Encode())
+	for {
+		var page []ModuleVersion
+		next, err := c.doURL(ctx, "GET", endpoint, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, page...)
+
+		if next == "" {
+			return versions, nil
+		}
+		endpoint, err = endpoint.Parse(next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page link %q: %w", next, err)
+		}
+	}
+}
+
+// YankModuleVersion marks the module version with the specified ID as yanked,
+// so that it is no longer offered to version constraints. Yanked versions can
+// still be downloaded by exact version.
+func (c *Client) YankModuleVersion(ctx context.Context, id, reason string) error {
+	if id == "" {
+		return errors.New("a module version ID is required")
+	}
+
+	body := yankModuleVersionRequest{Yanked: true, YankedReason: reason}
+	return c.do(ctx, "PATCH", "/api/terraform-module-versions/"+id, body, nil)
+}
+
+// Attachment is a file attached to a module version, Ex: a provenance
+// statement.
+type Attachment struct {
+	Name      string `json:"name"`
+	MediaType string `json:"media-type"`
+	Content   string `json:"content"`
+}
+
+// CreateAttachment attaches a file to the module version with the specified
+// ID, replacing any attachment with the same name.
+func (c *Client) CreateAttachment(ctx context.Context, id string, attachment Attachment) error {
+	if id == "" || attachment.Name == "" {
+		return errors.New("a module version ID and attachment name are required")
+	}
+
+	return c.do(ctx, "POST", "/api/terraform-module-versions/"+id+"/attachments", attachment, nil)
+}
+
+// GetAttachment returns the named attachment of the module version with the
+// specified ID.
+func (c *Client) GetAttachment(ctx context.Context, id, name string) (*Attachment, error) {
+	if id == "" || name == "" {
+		return nil, errors.New("a module version ID and attachment name are required")
+	}
+
+	var attachment Attachment
+	if err := c.do(ctx, "GET", "/api/terraform-module-versions/"+id+"/attachments/"+name, nil, &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
@@ -0,0 +1,115 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+)
+
+func TestModuleVersions(t *testing.T) {
+	var yanked, reason string
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/terraform-module-versions", func(res http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if query.Get("filter[namespace]") != "platform" || query.Get("filter[name]") != "vpc" || query.Get("filter[system]") != "aws" {
+			t.Errorf("unexpected query %q", req.URL.RawQuery)
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		if query.Get("page[number]") == "2" {
+			_, _ = res.Write([]byte(`{"data":[{"id":"mv-2","namespace":"platform","name":"vpc","system":"aws","version":"1.4.0-pr.13.1234567","yanked":false}],"links":{"next":null}}`))
+			return
+		}
+
+		query.Set("page[number]", "2")
+		_, _ = fmt.Fprintf(res, `{"data":[{"id":"mv-1","namespace":"platform","name":"vpc","system":"aws","version":"1.4.0-pr.12.abcdef1","yanked":false}],"links":{"next":%q}}`, "/api/terraform-module-versions?"+query.Encode())
+	})
+
+	mux.HandleFunc("PATCH /api/terraform-module-versions/{id}", func(res http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Data struct {
+				Yanked       bool   `json:"yanked"`
+				YankedReason string `json:"yanked-reason"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %s", err)
+		}
+		if !body.Data.Yanked {
+			t.Error("expected yanked to be true")
+		}
+
+		yanked = req.PathValue("id")
+		reason = body.Data.YankedReason
+		res.WriteHeader(http.StatusNoContent)
+	})
+
+	client := newTestServer(t, mux)
+	ctx := context.Background()
+
+	versions, err := client.ListModuleVersions(ctx, "platform", "vpc", "aws")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(versions) != 2 || versions[0].Version != "1.4.0-pr.12.abcdef1" || versions[1].Version != "1.4.0-pr.13.1234567" {
+		t.Errorf("expected the versions of both pages, got %+v", versions)
+	}
+
+	if err := client.YankModuleVersion(ctx, "mv-1", "pull request closed"); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if yanked != "mv-1" || reason != "pull request closed" {
+		t.Errorf("expected mv-1 to be yanked with a reason, got %q %q", yanked, reason)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	attachments := map[string]map[string]string{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/terraform-module-versions/{id}/attachments", func(res http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %s", err)
+		}
+		attachments[req.PathValue("id")+"/"+body.Data["name"]] = body.Data
+		res.WriteHeader(http.StatusCreated)
+	})
+
+	mux.HandleFunc("GET /api/terraform-module-versions/{id}/attachments/{name}", func(res http.ResponseWriter, req *http.Request) {
+		attachment, ok := attachments[req.PathValue("id")+"/"+req.PathValue("name")]
+		if !ok {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(res).Encode(map[string]any{"data": attachment})
+	})
+
+	client := newTestServer(t, mux)
+	ctx := context.Background()
+
+	err := client.CreateAttachment(ctx, "mv-1", api.Attachment{Name: "provenance.intoto.json", MediaType: "application/vnd.in-toto+json", Content: `{"_type":"x"}`})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	attachment, err := client.GetAttachment(ctx, "mv-1", "provenance.intoto.json")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if attachment.MediaType != "application/vnd.in-toto+json" || attachment.Content != `{"_type":"x"}` {
+		t.Errorf("unexpected attachment %+v", attachment)
+	}
+
+	if _, err := client.GetAttachment(ctx, "mv-2", "provenance.intoto.json"); !api.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Token is an API token. The secret value is only returned when the token is
+// created.
+type Token struct {
+	ID          string    `json:"id"`
+	Token       string    `json:"token,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Namespace   string    `json:"namespace"`
+	Scopes      []string  `json:"scopes"`
+	CreatedAt   time.Time `json:"created-at"`
+	ExpiresAt   time.Time `json:"expires-at"`
+}
+
+// CreateTokenOptions describes a restricted token to create.
+type CreateTokenOptions struct {
+	// Namespace restricts the token to a single namespace.
+	Namespace string
+	// Scopes lists the permissions granted to the token, Ex: "publish".
+	Scopes []string
+	// TTL is how long the token remains valid after it is created.
+	TTL         time.Duration
+	Description string
+}
+
+type createTokenRequest struct {
+	Namespace   string   `json:"namespace"`
+	Scopes      []string `json:"scopes"`
+	ExpiresIn   int64    `json:"expires-in"`
+	Description string   `json:"description,omitempty"`
+}
+
+// CreateToken mints a new token derived from the client's credentials. The
+// returned token includes its secret value.
+func (c *Client) CreateToken(ctx context.Context, opts CreateTokenOptions) (*Token, error) {
+	if opts.Namespace == "" {
+		return nil, errors.New("a namespace is required")
+	}
+	if len(opts.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	if opts.TTL < time.Second {
+		return nil, errors.New("the token TTL must be at least one second")
+	}
+
+	body := createTokenRequest{
+		Namespace:   opts.Namespace,
+		Scopes:      opts.Scopes,
+		ExpiresIn:   int64(opts.TTL / time.Second),
+		Description: opts.Description,
+	}
+
+	var token Token
+	if err := c.do(ctx, "POST", "/api/tokens", body, &token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// ListTokens returns the tokens created by the client's account.
+func (c *Client) ListTokens(ctx context.Context) ([]Token, error) {
+	var tokens []Token
+	if err := c.do(ctx, "GET", "/api/tokens", nil, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken revokes the token with the specified ID.
+func (c *Client) RevokeToken(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("a token ID is required")
+	}
+
+	return c.do(ctx, "DELETE", "/api/tokens/"+id, nil, nil)
+}
@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+)
+
+func TestTokens(t *testing.T) {
+	revoked := ""
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/tokens", func(res http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Data struct {
+				Namespace string   `json:"namespace"`
+				Scopes    []string `json:"scopes"`
+				ExpiresIn int64    `json:"expires-in"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %s", err)
+		}
+
+		if body.Data.Namespace != "platform" {
+			t.Errorf("expected namespace platform, got %q", body.Data.Namespace)
+		}
+		if len(body.Data.Scopes) != 1 || body.Data.Scopes[0] != "publish" {
+			t.Errorf("expected scopes [publish], got %v", body.Data.Scopes)
+		}
+		if body.Data.ExpiresIn != 3600 {
+			t.Errorf("expected expires-in 3600, got %d", body.Data.ExpiresIn)
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusCreated)
+		_, _ = res.Write([]byte(`{"data":{"id":"tok-1","token":"secret","namespace":"platform","scopes":["publish"],"created-at":"2024-10-20T10:00:00Z","expires-at":"2024-10-20T11:00:00Z"}}`))
+	})
+
+	mux.HandleFunc("GET /api/tokens", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(`{"data":[{"id":"tok-1","namespace":"platform","scopes":["publish"],"created-at":"2024-10-20T10:00:00Z","expires-at":"2024-10-20T11:00:00Z"}]}`))
+	})
+
+	mux.HandleFunc("DELETE /api/tokens/{id}", func(res http.ResponseWriter, req *http.Request) {
+		revoked = req.PathValue("id")
+		res.WriteHeader(http.StatusNoContent)
+	})
+
+	client := newTestServer(t, mux)
+	ctx := context.Background()
+
+	token, err := client.CreateToken(ctx, api.CreateTokenOptions{
+		Namespace: "platform",
+		Scopes:    []string{"publish"},
+		TTL:       time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if token.Token != "secret" {
+		t.Errorf("expected token secret to be returned, got %q", token.Token)
+	}
+	if token.ExpiresAt.Sub(token.CreatedAt) != time.Hour {
+		t.Errorf("unexpected expiry %s", token.ExpiresAt)
+	}
+
+	tokens, err := client.ListTokens(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != "tok-1" || tokens[0].Token != "" {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+
+	if err := client.RevokeToken(ctx, "tok-1"); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if revoked != "tok-1" {
+		t.Errorf("expected tok-1 to be revoked, got %q", revoked)
+	}
+}
+
+func TestCreateTokenValidation(t *testing.T) {
+	client := api.NewClient("localhost", "test-token")
+
+	_, err := client.CreateToken(context.Background(), api.CreateTokenOptions{
+		Namespace: "platform",
+		Scopes:    []string{"publish"},
+	})
+	if err == nil {
+		t.Error("expected an error when no TTL is given")
+	}
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cli/browser"
+	"github.com/cli/oauth"
+	"github.com/cli/oauth/api"
+)
+
+// CallbackPath is the path of the loopback redirect URI that receives the
+// authorization response.
+const CallbackPath = "/callback"
+
+// PKCEFlow performs the OAuth authorization code flow with PKCE (RFC 7636),
+// receiving the authorization response on a temporary loopback HTTP server.
+type PKCEFlow struct {
+	Host     *oauth.Host
+	ClientID string
+	Scopes   []string
+
+	// BrowseURL opens the authorization URL. Defaults to opening the system
+	// web browser.
+	BrowseURL func(string) error
+
+	// WriteSuccessHTML renders the page shown in the browser once the
+	// authorization response has been received.
+	WriteSuccessHTML func(io.Writer)
+
+	// HTTPClient is used to exchange the authorization code for a token.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// MinPort and MaxPort restrict the loopback port used for the redirect
+	// URI. If both are zero, any available port is used.
+	MinPort, MaxPort uint16
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// Run blocks until the user has authorized the client in the browser and
+// returns the access token issued in exchange for the authorization code.
+func (f PKCEFlow) Run(ctx context.Context) (*api.AccessToken, error) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+
+	state, err := randomString(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	listener, err := f.listen()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start loopback server: %w", err)
+	}
+	defer listener.Close()
+
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), CallbackPath)
+
+	authorizeURL, err := url.Parse(f.Host.AuthorizeURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization URL: %w", err)
+	}
+
+	q := authorizeURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", f.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", strings.Join(f.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	authorizeURL.RawQuery = q.Encode()
+
+	results := make(chan callbackResult, 1)
+	server := &http.Server{
+		Handler: f.callbackHandler(state, results),
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	browseURL := f.BrowseURL
+	if browseURL == nil {
+		browseURL = browser.OpenURL
+	}
+
+	log.Printf("[DEBUG] Waiting for authorization response on %s", redirectURI)
+
+	if err := browseURL(authorizeURL.String()); err != nil {
+		return nil, fmt.Errorf("error opening the web browser: %w", err)
+	}
+
+	var result callbackResult
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-results:
+	}
+
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	httpClient := f.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := api.PostForm(httpClient, f.Host.TokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {f.ClientID},
+		"code":          {result.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.AccessToken()
+}
+
+// listen binds the loopback server to the first available port in the
+// permitted range.
+func (f PKCEFlow) listen() (net.Listener, error) {
+	if f.MinPort == 0 && f.MaxPort == 0 {
+		return net.Listen("tcp4", "127.0.0.1:0")
+	}
+
+	var err error
+	for port := int(f.MinPort); port <= int(f.MaxPort); port++ {
+		var listener net.Listener
+		listener, err = net.Listen("tcp4", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			return listener, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no port available between %d and %d: %w", f.MinPort, f.MaxPort, err)
+}
+
+func (f PKCEFlow) callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(CallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		var result callbackResult
+		switch {
+		case params.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s", params.Get("error"))
+			if desc := params.Get("error_description"); desc != "" {
+				result.err = fmt.Errorf("%w (%s)", result.err, desc)
+			}
+		case params.Get("state") != state:
+			result.err = errors.New("authorization failed: state mismatch")
+		case params.Get("code") == "":
+			result.err = errors.New("authorization failed: no code was received")
+		default:
+			result.code = params.Get("code")
+		}
+
+		select {
+		case results <- result:
+		default:
+			// A response was already received; ignore repeated redirects.
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<p>%s</p>", html.EscapeString(result.err.Error()))
+			return
+		}
+
+		if f.WriteSuccessHTML != nil {
+			f.WriteSuccessHTML(w)
+		} else {
+			fmt.Fprint(w, "<p>You may now close this page and return to the terminal.</p>")
+		}
+	})
+
+	return mux
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,152 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cli/oauth"
+
+	"github.com/registry-tools/rt-cli/internal/auth"
+)
+
+func newTestOAuthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var challenge string
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login", func(res http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		if q.Get("code_challenge_method") != "S256" {
+			t.Errorf("expected code_challenge_method S256, got %q", q.Get("code_challenge_method"))
+		}
+		if q.Get("client_id") != "rt-cli" {
+			t.Errorf("expected client_id rt-cli, got %q", q.Get("client_id"))
+		}
+		challenge = q.Get("code_challenge")
+
+		redirect, err := url.Parse(q.Get("redirect_uri"))
+		if err != nil {
+			t.Fatalf("invalid redirect_uri: %s", err)
+		}
+		if redirect.Hostname() != "127.0.0.1" {
+			t.Errorf("expected loopback redirect_uri, got %q", redirect)
+		}
+
+		rq := redirect.Query()
+		rq.Set("code", "test-code")
+		rq.Set("state", q.Get("state"))
+		redirect.RawQuery = rq.Encode()
+
+		http.Redirect(res, req, redirect.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/auth/token", func(res http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %s", err)
+		}
+
+		if req.PostForm.Get("grant_type") != "authorization_code" {
+			t.Errorf("expected grant_type authorization_code, got %q", req.PostForm.Get("grant_type"))
+		}
+		if req.PostForm.Get("code") != "test-code" {
+			t.Errorf("expected code test-code, got %q", req.PostForm.Get("code"))
+		}
+
+		sum := sha256.Sum256([]byte(req.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			res.Header().Set("Content-Type", "application/json")
+			res.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(res).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(res).Encode(map[string]any{
+			"access_token": "pkce-token",
+			"token_type":   "bearer",
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPKCEFlow(t *testing.T) {
+	server := newTestOAuthServer(t)
+
+	flow := auth.PKCEFlow{
+		Host: &oauth.Host{
+			AuthorizeURL: server.URL + "/login",
+			TokenURL:     server.URL + "/auth/token",
+		},
+		ClientID: "rt-cli",
+		Scopes:   []string{"owner"},
+		BrowseURL: func(u string) error {
+			// Stand in for the browser by following the redirect back to the
+			// loopback server.
+			go func() {
+				res, err := http.Get(u)
+				if err != nil {
+					t.Errorf("browser request failed: %s", err)
+					return
+				}
+				res.Body.Close()
+			}()
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := flow.Run(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if token.Token != "pkce-token" {
+		t.Errorf("expected token %q, got %q", "pkce-token", token.Token)
+	}
+}
+
+func TestPKCEFlowAuthorizationDenied(t *testing.T) {
+	flow := auth.PKCEFlow{
+		Host: &oauth.Host{
+			AuthorizeURL: "http://127.0.0.1:1/login",
+			TokenURL:     "http://127.0.0.1:1/auth/token",
+		},
+		ClientID: "rt-cli",
+		BrowseURL: func(u string) error {
+			parsed, err := url.Parse(u)
+			if err != nil {
+				return err
+			}
+			redirect := parsed.Query().Get("redirect_uri") + "?error=access_denied"
+			go func() {
+				res, err := http.Get(redirect)
+				if err == nil {
+					res.Body.Close()
+				}
+			}()
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := flow.Run(ctx)
+	if err == nil || !strings.Contains(err.Error(), "access_denied") {
+		t.Errorf("expected access_denied error, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package ci
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Buildkite integrates with Buildkite using the buildkite-agent CLI. Inputs are
+// read from RT_* variables, outputs are stored as build meta-data and
+// summaries and annotations are added as build annotations.
+type Buildkite struct {
+	getenv func(string) string
+	stdout io.Writer
+
+	// agent runs a buildkite-agent subcommand with the specified stdin.
+	agent func(stdin io.Reader, args ...string) error
+}
+
+// NewBuildkite returns a Buildkite provider that reads the specified
+// environment.
+func NewBuildkite(getenv func(string) string) *Buildkite {
+	return &Buildkite{
+		getenv: getenv,
+		stdout: os.Stdout,
+		agent:  runBuildkiteAgent,
+	}
+}
+
+func runBuildkiteAgent(stdin io.Reader, args ...string) error {
+	cmd := exec.Command("buildkite-agent", args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("buildkite-agent %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+func (p *Buildkite) Name() string {
+	return "Buildkite"
+}
+
+func (p *Buildkite) Input(name string) string {
+	return p.getenv(envName(name))
+}
+
+// SetOutput stores the value as meta-data with the key "rt-<name>".
+func (p *Buildkite) SetOutput(name, value string) error {
+	return p.agent(strings.NewReader(value), "meta-data", "set", "rt-"+name)
+}
+
+func (p *Buildkite) AddSummary(content string) error {
+	return p.agent(strings.NewReader(content), "annotate", "--style", "success", "--context", "rt-summary", "--append")
+}
+
+func (p *Buildkite) Annotate(a Annotation) error {
+	style := "info"
+	switch a.Level {
+	case LevelError:
+		style = "error"
+	case LevelWarning:
+		style = "warning"
+	}
+
+	body := fmt.Sprintf("<p>%s</p>\n", html.EscapeString(a.String()))
+	return p.agent(strings.NewReader(body), "annotate", "--style", style, "--context", "rt-"+style, "--append")
+}
+
+// Group starts a collapsed log group. Buildkite groups end when the next one
+// begins.
+func (p *Buildkite) Group(title string) {
+	fmt.Fprintf(p.stdout, "--- %s\n", title)
+}
+
+func (p *Buildkite) EndGroup() {}
+
+func (p *Buildkite) Repository() string {
+	return repositoryFromURL(p.getenv("BUILDKITE_REPO"))
+}
+
+func (p *Buildkite) Build() Build {
+	build := Build{
+		Workflow:      p.getenv("BUILDKITE_PIPELINE_SLUG"),
+		RepositoryURL: p.getenv("BUILDKITE_REPO"),
+		Commit:        p.getenv("BUILDKITE_COMMIT"),
+		Ref:           p.getenv("BUILDKITE_BRANCH"),
+	}
+	if org := p.getenv("BUILDKITE_ORGANIZATION_SLUG"); org != "" && build.Workflow != "" {
+		build.BuilderID = fmt.Sprintf("https://buildkite.com/%s/%s", org, build.Workflow)
+	}
+	if url := p.getenv("BUILDKITE_BUILD_URL"); url != "" {
+		build.InvocationID = url + "#" + p.getenv("BUILDKITE_JOB_ID")
+	}
+	return build
+}
@@ -0,0 +1,158 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Level is the severity of an annotation.
+type Level string
+
+const (
+	LevelNotice  Level = "notice"
+	LevelWarning Level = "warning"
+	LevelError   Level = "error"
+)
+
+// Annotation is a message surfaced prominently by the CI system, optionally
+// attached to a location in the source.
+type Annotation struct {
+	Level   Level
+	Title   string
+	Message string
+	File    string
+	Line    int
+}
+
+func (a Annotation) String() string {
+	var b strings.Builder
+	if a.File != "" {
+		b.WriteString(a.File)
+		if a.Line > 0 {
+			fmt.Fprintf(&b, ":%d", a.Line)
+		}
+		b.WriteString(": ")
+	}
+	if a.Title != "" {
+		b.WriteString(a.Title)
+		b.WriteString(": ")
+	}
+	b.WriteString(a.Message)
+	return b.String()
+}
+
+// Provider abstracts the features of a CI system that are used when publishing
+// from a pipeline.
+type Provider interface {
+	// Name returns the name of the CI system, Ex: "GitHub Actions".
+	Name() string
+
+	// Input returns the value of a named input, Ex: "version", or an empty
+	// string if it was not provided.
+	Input(name string) string
+
+	// SetOutput makes a value available to later steps or jobs.
+	SetOutput(name, value string) error
+
+	// AddSummary adds HTML content to the summary of the job.
+	AddSummary(content string) error
+
+	// Annotate reports a message to the CI system.
+	Annotate(a Annotation) error
+
+	// Group starts a collapsible section of the job log, which continues
+	// until EndGroup is called.
+	Group(title string)
+
+	// EndGroup ends the current section of the job log.
+	EndGroup()
+
+	// Repository returns the path of the repository being built, Ex:
+	// "organization/repository", or an empty string if it is unknown.
+	Repository() string
+
+	// Build describes the running job, for recording how a module was built.
+	Build() Build
+}
+
+// Build describes a CI job. Fields are empty when they are unknown.
+type Build struct {
+	// BuilderID identifies the workflow or pipeline that ran the job, Ex:
+	// "https://github.com/org/repo/.github/workflows/release.yml@refs/heads/main".
+	BuilderID string
+
+	// InvocationID identifies this run of the job, Ex: the URL of the run.
+	InvocationID string
+
+	// Workflow is the name or path of the workflow or pipeline definition.
+	Workflow string
+
+	// RepositoryURL, Commit and Ref identify the source being built.
+	RepositoryURL string
+	Commit        string
+	Ref           string
+}
+
+// Detect returns the provider for the CI system described by the environment,
+// falling back to the generic environment variable provider.
+func Detect(getenv func(string) string) Provider {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return NewGitHubActions(getenv)
+	case getenv("GITLAB_CI") == "true":
+		return NewGitLab(getenv)
+	case getenv("BUILDKITE") == "true":
+		return NewBuildkite(getenv)
+	default:
+		return NewEnv(getenv)
+	}
+}
+
+// DetectFromEnvironment is Detect using the process environment.
+func DetectFromEnvironment() Provider {
+	return Detect(os.Getenv)
+}
+
+// envName returns the environment variable used to pass an input or output to
+// providers that have no native equivalent. Ex: "module-version" becomes
+// "RT_MODULE_VERSION".
+func envName(name string) string {
+	return "RT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// repositoryFromURL returns the "organization/repository" path of a git
+// remote URL such as "git@github.com:org/repo.git" or
+// "https://github.com/org/repo.git".
+func repositoryFromURL(remote string) string {
+	remote = strings.TrimSuffix(remote, ".git")
+
+	if i := strings.Index(remote, "://"); i >= 0 {
+		remote = remote[i+3:]
+		if _, path, ok := strings.Cut(remote, "/"); ok {
+			return path
+		}
+		return ""
+	}
+
+	if _, path, ok := strings.Cut(remote, ":"); ok {
+		return path
+	}
+
+	return ""
+}
+
+// appendToFile appends content to the file at path, creating it if needed.
+func appendToFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
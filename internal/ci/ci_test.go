@@ -0,0 +1,296 @@
+package ci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+func getenvFrom(env map[string]string) func(string) string {
+	return func(k string) string { return env[k] }
+}
+
+func TestDetect(t *testing.T) {
+	cases := map[string]struct {
+		env      map[string]string
+		expected string
+	}{
+		"github":    {map[string]string{"GITHUB_ACTIONS": "true"}, "GitHub Actions"},
+		"gitlab":    {map[string]string{"GITLAB_CI": "true"}, "GitLab CI/CD"},
+		"buildkite": {map[string]string{"BUILDKITE": "true"}, "Buildkite"},
+		"generic":   {map[string]string{"CI": "true"}, "CI"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := Detect(getenvFrom(c.env)).Name(); actual != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGitHubActions(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{
+		"INPUT_VERSION":     "1.2.3",
+		"GITHUB_OUTPUT":     filepath.Join(dir, "output"),
+		"GITHUB_REPOSITORY": "org/terraform-aws-vpc",
+	}
+
+	var stdout bytes.Buffer
+	p := &GitHubActions{Action: githubactions.New(githubactions.WithGetenv(getenvFrom(env)), githubactions.WithWriter(&stdout))}
+
+	if p.Input("version") != "1.2.3" {
+		t.Errorf("expected version input 1.2.3, got %q", p.Input("version"))
+	}
+	if p.Repository() != "org/terraform-aws-vpc" {
+		t.Errorf("unexpected repository %q", p.Repository())
+	}
+
+	if err := p.SetOutput("source", "example.com/org/vpc/aws"); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	output, _ := os.ReadFile(env["GITHUB_OUTPUT"])
+	if !strings.Contains(string(output), "example.com/org/vpc/aws") {
+		t.Errorf("expected output file to contain the source, got %q", output)
+	}
+
+	if err := p.Annotate(Annotation{Level: LevelError, File: "main.tf", Line: 3, Message: "bad"}); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !strings.Contains(stdout.String(), "::error file=main.tf,line=3::bad") {
+		t.Errorf("unexpected annotation %q", stdout.String())
+	}
+
+	stdout.Reset()
+	p.Group("Pack module")
+	p.EndGroup()
+	if stdout.String() != "::group::Pack module\n::endgroup::\n" {
+		t.Errorf("unexpected group commands %q", stdout.String())
+	}
+}
+
+func TestGitHubActionsBuild(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_SERVER_URL":   "https://github.com",
+		"GITHUB_REPOSITORY":   "org/terraform-aws-vpc",
+		"GITHUB_WORKFLOW_REF": "org/terraform-aws-vpc/.github/workflows/release.yml@refs/tags/v1.2.3",
+		"GITHUB_SHA":          "0123456789abcdef",
+		"GITHUB_REF":          "refs/tags/v1.2.3",
+		"GITHUB_RUN_ID":       "42",
+		"GITHUB_RUN_ATTEMPT":  "2",
+	}
+
+	expected := Build{
+		BuilderID:     "https://github.com/org/terraform-aws-vpc/.github/workflows/release.yml@refs/tags/v1.2.3",
+		InvocationID:  "https://github.com/org/terraform-aws-vpc/actions/runs/42/attempts/2",
+		Workflow:      "org/terraform-aws-vpc/.github/workflows/release.yml@refs/tags/v1.2.3",
+		RepositoryURL: "https://github.com/org/terraform-aws-vpc",
+		Commit:        "0123456789abcdef",
+		Ref:           "refs/tags/v1.2.3",
+	}
+	if actual := NewGitHubActions(getenvFrom(env)).Build(); actual != expected {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+
+	if actual := NewEnv(getenvFrom(env)).Build(); actual != (Build{}) {
+		t.Errorf("expected the generic provider to know nothing about the build, got %+v", actual)
+	}
+}
+
+func TestGitHubActionsCommentOnPullRequest(t *testing.T) {
+	var created, updated []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/org/repo/issues/{number}/comments", func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer gh-token" {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case req.PathValue("number") == "1":
+			_, _ = res.Write([]byte(`[{"id":10,"body":"unrelated"},{"id":11,"body":"<!-- marker -->\nold"}]`))
+		case req.PathValue("number") == "3" && req.URL.Query().Get("page") == "1":
+			comments := make([]issueComment, commentsPerPage)
+			for i := range comments {
+				comments[i] = issueComment{ID: int64(100 + i), Body: "unrelated"}
+			}
+			_ = json.NewEncoder(res).Encode(comments)
+		case req.PathValue("number") == "3" && req.URL.Query().Get("page") == "2":
+			_, _ = res.Write([]byte(`[{"id":12,"body":"<!-- marker -->\nold"}]`))
+		default:
+			_, _ = res.Write([]byte(`[]`))
+		}
+	})
+	mux.HandleFunc("POST /repos/org/repo/issues/{number}/comments", func(res http.ResponseWriter, req *http.Request) {
+		var comment issueComment
+		_ = json.NewDecoder(req.Body).Decode(&comment)
+		created = append(created, req.PathValue("number")+": "+comment.Body)
+		res.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("PATCH /repos/org/repo/issues/comments/{id}", func(res http.ResponseWriter, req *http.Request) {
+		var comment issueComment
+		_ = json.NewDecoder(req.Body).Decode(&comment)
+		updated = append(updated, req.PathValue("id")+": "+comment.Body)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	env := map[string]string{
+		"GITHUB_REPOSITORY": "org/repo",
+		"GITHUB_API_URL":    server.URL,
+		"GITHUB_TOKEN":      "gh-token",
+	}
+	p := &GitHubActions{Action: githubactions.New(githubactions.WithGetenv(getenvFrom(env)), githubactions.WithWriter(io.Discard))}
+
+	ctx := context.Background()
+	if err := p.CommentOnPullRequest(ctx, 1, "<!-- marker -->", "new"); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if err := p.CommentOnPullRequest(ctx, 2, "<!-- marker -->", "new"); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if err := p.CommentOnPullRequest(ctx, 3, "<!-- marker -->", "new"); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if len(updated) != 2 || updated[0] != "11: <!-- marker -->\nnew" || updated[1] != "12: <!-- marker -->\nnew" {
+		t.Errorf("expected the marked comments to be updated, got %q", updated)
+	}
+	if len(created) != 1 || created[0] != "2: <!-- marker -->\nnew" {
+		t.Errorf("expected a comment to be added, got %q", created)
+	}
+
+	delete(env, "GITHUB_TOKEN")
+	if err := p.CommentOnPullRequest(ctx, 1, "<!-- marker -->", "new"); err == nil {
+		t.Error("expected an error without a token")
+	}
+}
+
+func TestGitLab(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{
+		"RT_VERSION":      "1.2.3",
+		"RT_DOTENV_FILE":  filepath.Join(dir, "rt.env"),
+		"RT_SUMMARY_FILE": filepath.Join(dir, "summary.html"),
+		"CI_PROJECT_PATH": "group/terraform-aws-vpc",
+	}
+
+	p := NewGitLab(getenvFrom(env))
+	p.stdout = io.Discard
+
+	if p.Input("version") != "1.2.3" {
+		t.Errorf("expected version input 1.2.3, got %q", p.Input("version"))
+	}
+	if p.Repository() != "group/terraform-aws-vpc" {
+		t.Errorf("unexpected repository %q", p.Repository())
+	}
+
+	_ = p.SetOutput("source", "example.com/org/vpc/aws")
+	_ = p.SetOutput("module-version-id", "mv-1")
+	dotenv, _ := os.ReadFile(env["RT_DOTENV_FILE"])
+	if string(dotenv) != "RT_SOURCE=example.com/org/vpc/aws\nRT_MODULE_VERSION_ID=mv-1\n" {
+		t.Errorf("unexpected dotenv contents %q", dotenv)
+	}
+
+	if err := p.SetOutput("summary", "a\nb"); err == nil {
+		t.Error("expected an error for a multiline output")
+	}
+
+	var stdout bytes.Buffer
+	p.stdout = &stdout
+	p.now = func() time.Time { return time.Unix(1700000000, 0) }
+	p.Group("Pack module")
+	p.EndGroup()
+	expectedLog := "\x1b[0Ksection_start:1700000000:rt_pack_module[collapsed=true]\r\x1b[0KPack module\n" +
+		"\x1b[0Ksection_end:1700000000:rt_pack_module\r\x1b[0K\n"
+	if stdout.String() != expectedLog {
+		t.Errorf("unexpected section markers %q", stdout.String())
+	}
+
+	_ = p.AddSummary("<h3>Published</h3>")
+	summary, _ := os.ReadFile(env["RT_SUMMARY_FILE"])
+	if string(summary) != "<h3>Published</h3>" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
+
+func TestBuildkite(t *testing.T) {
+	env := map[string]string{
+		"RT_NAMESPACE":   "platform",
+		"BUILDKITE_REPO": "git@github.com:org/terraform-aws-vpc.git",
+	}
+
+	var calls []string
+	p := NewBuildkite(getenvFrom(env))
+	p.agent = func(stdin io.Reader, args ...string) error {
+		data, _ := io.ReadAll(stdin)
+		calls = append(calls, strings.Join(args, " ")+" < "+string(data))
+		return nil
+	}
+
+	if p.Input("namespace") != "platform" {
+		t.Errorf("expected namespace input platform, got %q", p.Input("namespace"))
+	}
+	if p.Repository() != "org/terraform-aws-vpc" {
+		t.Errorf("unexpected repository %q", p.Repository())
+	}
+
+	_ = p.SetOutput("version", "1.2.3")
+	_ = p.Annotate(Annotation{Level: LevelWarning, Message: "large <archive>"})
+
+	expected := []string{
+		"meta-data set rt-version < 1.2.3",
+		"annotate --style warning --context rt-warning --append < <p>large &lt;archive&gt;</p>\n",
+	}
+	if strings.Join(calls, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected buildkite-agent calls %q", calls)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	p := NewEnv(getenvFrom(map[string]string{"RT_REPOSITORY": "org/repo"}))
+	p.stdout = &stdout
+	p.stderr = &stderr
+
+	_ = p.SetOutput("version", "1.2.3")
+	_ = p.Annotate(Annotation{Level: LevelError, File: "main.tf", Message: "bad"})
+
+	if stdout.String() != "version=1.2.3\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	if stderr.String() != "ERROR: main.tf: bad\n" {
+		t.Errorf("unexpected annotation %q", stderr.String())
+	}
+	if p.Repository() != "org/repo" {
+		t.Errorf("unexpected repository %q", p.Repository())
+	}
+}
+
+func TestRepositoryFromURL(t *testing.T) {
+	items := map[string]string{
+		"git@github.com:org/repo.git":       "org/repo",
+		"https://github.com/org/repo.git":   "org/repo",
+		"https://gitlab.com/group/sub/repo": "group/sub/repo",
+		"ssh://git@example.com:22/org/repo": "org/repo",
+		"":                                  "",
+	}
+
+	for remote, expected := range items {
+		if actual := repositoryFromURL(remote); actual != expected {
+			t.Errorf("expected %q to be %q, but was %q", remote, expected, actual)
+		}
+	}
+}
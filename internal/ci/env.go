@@ -0,0 +1,73 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Env is a generic provider for CI systems without a dedicated integration.
+// Inputs are read from RT_* variables, Ex: RT_VERSION. Outputs are appended as
+// name=value lines to the file named by RT_OUTPUT_FILE, or printed if it is
+// not set, and the summary is written to RT_SUMMARY_FILE if it is set.
+type Env struct {
+	getenv func(string) string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewEnv returns a generic provider that reads the specified environment.
+func NewEnv(getenv func(string) string) *Env {
+	return &Env{getenv: getenv, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+func (p *Env) Name() string {
+	return "CI"
+}
+
+func (p *Env) Input(name string) string {
+	return p.getenv(envName(name))
+}
+
+func (p *Env) SetOutput(name, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("output %q cannot contain newlines", name)
+	}
+
+	line := fmt.Sprintf("%s=%s\n", name, value)
+	if path := p.getenv("RT_OUTPUT_FILE"); path != "" {
+		return appendToFile(path, line)
+	}
+
+	_, err := io.WriteString(p.stdout, line)
+	return err
+}
+
+func (p *Env) AddSummary(content string) error {
+	if path := p.getenv("RT_SUMMARY_FILE"); path != "" {
+		return appendToFile(path, content)
+	}
+	return nil
+}
+
+func (p *Env) Annotate(a Annotation) error {
+	_, err := fmt.Fprintf(p.stderr, "%s: %s\n", strings.ToUpper(string(a.Level)), a)
+	return err
+}
+
+func (p *Env) Group(title string) {
+	fmt.Fprintf(p.stderr, "==> %s\n", title)
+}
+
+func (p *Env) EndGroup() {}
+
+func (p *Env) Repository() string {
+	return p.getenv("RT_REPOSITORY")
+}
+
+// Build returns an empty description, because nothing is known about the
+// job.
+func (p *Env) Build() Build {
+	return Build{}
+}
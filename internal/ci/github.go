@@ -0,0 +1,104 @@
+package ci
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+// GitHubActions uses workflow commands and environment files to integrate
+// with GitHub Actions.
+type GitHubActions struct {
+	Action *githubactions.Action
+
+	// HTTPClient is used for GitHub API requests, and defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewGitHubActions returns a GitHub Actions provider that reads the specified
+// environment.
+func NewGitHubActions(getenv func(string) string) *GitHubActions {
+	return &GitHubActions{
+		Action: githubactions.New(githubactions.WithGetenv(getenv)),
+	}
+}
+
+func (p *GitHubActions) Name() string {
+	return "GitHub Actions"
+}
+
+func (p *GitHubActions) Input(name string) string {
+	return p.Action.GetInput(name)
+}
+
+func (p *GitHubActions) SetOutput(name, value string) error {
+	p.Action.SetOutput(name, value)
+	return nil
+}
+
+func (p *GitHubActions) AddSummary(content string) error {
+	p.Action.AddStepSummary(content)
+	return nil
+}
+
+func (p *GitHubActions) Annotate(a Annotation) error {
+	fields := map[string]string{}
+	if a.File != "" {
+		fields["file"] = a.File
+	}
+	if a.Line > 0 {
+		fields["line"] = strconv.Itoa(a.Line)
+	}
+	if a.Title != "" {
+		fields["title"] = a.Title
+	}
+
+	action := p.Action.WithFieldsMap(fields)
+	switch a.Level {
+	case LevelError:
+		action.Errorf("%s", a.Message)
+	case LevelWarning:
+		action.Warningf("%s", a.Message)
+	default:
+		action.Noticef("%s", a.Message)
+	}
+
+	return nil
+}
+
+func (p *GitHubActions) Group(title string) {
+	p.Action.Group(title)
+}
+
+func (p *GitHubActions) EndGroup() {
+	p.Action.EndGroup()
+}
+
+func (p *GitHubActions) Repository() string {
+	return p.Action.Getenv("GITHUB_REPOSITORY")
+}
+
+func (p *GitHubActions) Build() Build {
+	getenv := p.Action.Getenv
+	server := getenv("GITHUB_SERVER_URL")
+	repository := getenv("GITHUB_REPOSITORY")
+
+	build := Build{
+		Workflow: getenv("GITHUB_WORKFLOW_REF"),
+		Commit:   getenv("GITHUB_SHA"),
+		Ref:      getenv("GITHUB_REF"),
+	}
+	if server != "" && repository != "" {
+		build.RepositoryURL = fmt.Sprintf("%s/%s", server, repository)
+		if runID := getenv("GITHUB_RUN_ID"); runID != "" {
+			build.InvocationID = fmt.Sprintf("%s/%s/actions/runs/%s/attempts/%s", server, repository, runID, getenv("GITHUB_RUN_ATTEMPT"))
+		}
+	}
+	if server != "" && build.Workflow != "" {
+		build.BuilderID = fmt.Sprintf("%s/%s", server, build.Workflow)
+	}
+	return build
+}
@@ -0,0 +1,118 @@
+package ci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// commentsPerPage is the number of pull request comments requested at a time
+// when looking for a previous comment, the most GitHub allows.
+const commentsPerPage = 100
+
+type issueComment struct {
+	ID   int64  `json:"id,omitempty"`
+	Body string `json:"body"`
+}
+
+// CommentOnPullRequest adds a comment to pull request number of the
+// repository being built. If a previous comment contains marker, it is updated
+// instead so that repeated runs do not add more comments. Requests are
+// authenticated with the "github-token" input or GITHUB_TOKEN.
+func (p *GitHubActions) CommentOnPullRequest(ctx context.Context, number int, marker, body string) error {
+	token := p.Action.GetInput("github-token")
+	if token == "" {
+		token = p.Action.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		return errors.New("the github-token input or GITHUB_TOKEN must be set to comment on pull requests")
+	}
+
+	repository := p.Repository()
+	if repository == "" {
+		return errors.New("GITHUB_REPOSITORY is not set")
+	}
+
+	apiURL := p.Action.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	commentsURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments", strings.TrimSuffix(apiURL, "/"), repository, number)
+
+	body = marker + "\n" + body
+
+	for page := 1; ; page++ {
+		var existing []issueComment
+		pageURL := fmt.Sprintf("%s?per_page=%d&page=%d", commentsURL, commentsPerPage, page)
+		if err := p.githubRequest(ctx, token, "GET", pageURL, nil, &existing); err != nil {
+			return fmt.Errorf("failed to list pull request comments: %w", err)
+		}
+
+		for _, comment := range existing {
+			if strings.Contains(comment.Body, marker) {
+				commentURL := fmt.Sprintf("%s/repos/%s/issues/comments/%d", strings.TrimSuffix(apiURL, "/"), repository, comment.ID)
+				if err := p.githubRequest(ctx, token, "PATCH", commentURL, issueComment{Body: body}, nil); err != nil {
+					return fmt.Errorf("failed to update pull request comment: %w", err)
+				}
+				return nil
+			}
+		}
+
+		if len(existing) < commentsPerPage {
+			break
+		}
+	}
+
+	if err := p.githubRequest(ctx, token, "POST", commentsURL, issueComment{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to add pull request comment: %w", err)
+	}
+	return nil
+}
+
+func (p *GitHubActions) githubRequest(ctx context.Context, token, method, url string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("GitHub API returned %s", res.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,118 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// GitLab integrates with GitLab CI/CD. Inputs are read from RT_* variables
+// and outputs are written to a dotenv file which should be declared as an
+// `artifacts:reports:dotenv` report so that later jobs receive them as
+// variables.
+type GitLab struct {
+	getenv func(string) string
+	stdout io.Writer
+
+	section string
+	now     func() time.Time
+}
+
+// NewGitLab returns a GitLab CI/CD provider that reads the specified
+// environment.
+func NewGitLab(getenv func(string) string) *GitLab {
+	return &GitLab{getenv: getenv, stdout: os.Stdout, now: time.Now}
+}
+
+func (p *GitLab) Name() string {
+	return "GitLab CI/CD"
+}
+
+func (p *GitLab) Input(name string) string {
+	return p.getenv(envName(name))
+}
+
+// DotenvPath is the file that outputs are written to. Defaults to "rt.env",
+// and may be changed with RT_DOTENV_FILE.
+func (p *GitLab) DotenvPath() string {
+	if path := p.getenv("RT_DOTENV_FILE"); path != "" {
+		return path
+	}
+	return "rt.env"
+}
+
+// SummaryPath is the file that the job summary is written to. Defaults to
+// "rt-summary.html", and may be changed with RT_SUMMARY_FILE. Expose it with
+// `artifacts:expose_as` to link it from merge requests.
+func (p *GitLab) SummaryPath() string {
+	if path := p.getenv("RT_SUMMARY_FILE"); path != "" {
+		return path
+	}
+	return "rt-summary.html"
+}
+
+func (p *GitLab) SetOutput(name, value string) error {
+	// dotenv reports do not support multiline values
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("output %q cannot contain newlines in a dotenv report", name)
+	}
+
+	return appendToFile(p.DotenvPath(), fmt.Sprintf("%s=%s\n", envName(name), value))
+}
+
+func (p *GitLab) AddSummary(content string) error {
+	return appendToFile(p.SummaryPath(), content)
+}
+
+func (p *GitLab) Annotate(a Annotation) error {
+	color := "\x1b[36m"
+	switch a.Level {
+	case LevelError:
+		color = "\x1b[31;1m"
+	case LevelWarning:
+		color = "\x1b[33;1m"
+	}
+
+	_, err := fmt.Fprintf(p.stdout, "%s%s:\x1b[0m %s\n", color, strings.ToUpper(string(a.Level)), a)
+	return err
+}
+
+var sectionNameInvalid = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// Group starts a collapsed job log section.
+func (p *GitLab) Group(title string) {
+	p.EndGroup()
+
+	p.section = "rt_" + strings.Trim(sectionNameInvalid.ReplaceAllString(strings.ToLower(title), "_"), "_")
+	fmt.Fprintf(p.stdout, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n", p.now().Unix(), p.section, title)
+}
+
+func (p *GitLab) EndGroup() {
+	if p.section == "" {
+		return
+	}
+
+	fmt.Fprintf(p.stdout, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", p.now().Unix(), p.section)
+	p.section = ""
+}
+
+func (p *GitLab) Repository() string {
+	return p.getenv("CI_PROJECT_PATH")
+}
+
+func (p *GitLab) Build() Build {
+	build := Build{
+		InvocationID:  p.getenv("CI_JOB_URL"),
+		Workflow:      p.getenv("CI_CONFIG_PATH"),
+		RepositoryURL: p.getenv("CI_PROJECT_URL"),
+		Commit:        p.getenv("CI_COMMIT_SHA"),
+		Ref:           p.getenv("CI_COMMIT_REF_NAME"),
+	}
+	if build.RepositoryURL != "" && build.Workflow != "" {
+		build.BuilderID = fmt.Sprintf("%s/-/blob/%s/%s", build.RepositoryURL, build.Commit, build.Workflow)
+	}
+	return build
+}
@@ -1,41 +1,169 @@
 package commands
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/discovery"
+	"github.com/registry-tools/rt-cli/internal/registry"
 	userconfig "github.com/registry-tools/rt-cli/internal/userconfig"
 	sdk "github.com/registry-tools/rt-sdk"
 )
 
-func GetSDK() (sdk.SDK, error) {
+// registryHostname returns the hostname of the registry configured in the
+// environment, or the default hostname.
+func registryHostname() string {
 	host := os.Getenv("REGISTRY_TOOLS_HOSTNAME")
 	if host == "" {
 		host = DefaultHostname
 	}
+	return host
+}
 
-	configuredByUserConfig := false
-	var token string
+// sdkHost returns the host that the SDK should use to reach the API of the
+// specified registry, as advertised by its rt.v1 service.
+func sdkHost(hostname string) (string, error) {
+	apiURL, err := discovery.Default.APIURL(hostname)
+	if err != nil {
+		return "", err
+	}
+
+	if apiURL.Scheme != "https" || strings.Trim(apiURL.Path, "/") != "" {
+		return "", fmt.Errorf("%s endpoint %q is not supported: the API must be served over https at the root of a host", discovery.APIServiceID, apiURL)
+	}
+
+	if apiURL.Host != hostname {
+		log.Printf("[DEBUG] Using API host %q for %q", apiURL.Host, hostname)
+	}
+
+	return apiURL.Host, nil
+}
+
+// credentials are the means of authenticating to a registry, in order of
+// precedence: a token from the environment, a client ID and secret from the
+// environment, or a token stored by `rt login`.
+type credentials struct {
+	token        string
+	clientID     string
+	clientSecret string
+	source       string
+}
+
+func credentialsForHost(host string) (*credentials, error) {
 	userconfig, err := userconfig.LoadFromUserConfigDirectory()
 	if err != nil {
 		return nil, err
 	}
 
-	token, configuredByUserConfig = userconfig.GetHostToken(host)
+	token, configuredByUserConfig := userconfig.GetHostToken(host)
 	envClientID := os.Getenv("REGISTRY_TOOLS_CLIENT_ID")
 	envClientSecret := os.Getenv("REGISTRY_TOOLS_CLIENT_SECRET")
 	envToken := os.Getenv("REGISTRY_TOOLS_TOKEN")
 
 	if envToken != "" {
-		log.Printf("[TRACE] Initializing SDK using token from environment")
-		return sdk.NewSDKWithAccessToken(host, envToken)
+		return &credentials{token: envToken, source: "token from environment"}, nil
 	} else if envClientID != "" && envClientSecret != "" {
-		log.Printf("[TRACE] Initializing SDK using client ID and secret from environment")
-		return sdk.NewSDK(host, envClientID, envClientSecret)
+		return &credentials{clientID: envClientID, clientSecret: envClientSecret, source: "client ID and secret from environment"}, nil
 	} else if configuredByUserConfig {
-		log.Printf("[TRACE] Initializing SDK using token from user config")
-		return sdk.NewSDKWithAccessToken(host, token)
+		return &credentials{token: token, source: "token from user config"}, nil
 	}
 
 	return nil, ErrLoginRequired
 }
+
+func GetSDK() (sdk.SDK, error) {
+	return getSDKForHost(registryHostname())
+}
+
+func getSDKForHost(host string) (sdk.SDK, error) {
+	creds, err := credentialsForHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	apiHost, err := sdkHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[TRACE] Initializing SDK using %s", creds.source)
+	if creds.token == "" {
+		return sdk.NewSDK(apiHost, creds.clientID, creds.clientSecret)
+	}
+	return sdk.NewSDKWithAccessToken(apiHost, creds.token)
+}
+
+// tokenForHost returns a token for the registry at host. Client IDs and
+// secrets are exchanged for a token at the host's token endpoint.
+func tokenForHost(ctx context.Context, host string) (string, error) {
+	creds, err := credentialsForHost(host)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("[TRACE] Authenticating to %s using %s", host, creds.source)
+
+	if creds.token != "" {
+		return creds.token, nil
+	}
+
+	login, err := discovery.Default.Login(host)
+	if err != nil {
+		return "", err
+	}
+
+	config := clientcredentials.Config{
+		ClientID:     creds.clientID,
+		ClientSecret: creds.clientSecret,
+		TokenURL:     login.Host.TokenURL,
+	}
+
+	t, err := config.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange client credentials for a token: %w", err)
+	}
+	return t.AccessToken, nil
+}
+
+// GetAPIClient returns a client for API endpoints that are not covered by the
+// SDK.
+func GetAPIClient(ctx context.Context) (*api.Client, error) {
+	return getAPIClientForHost(ctx, registryHostname())
+}
+
+// getAPIClientForHost returns a client for the API of the registry at host.
+func getAPIClientForHost(ctx context.Context, host string) (*api.Client, error) {
+	apiURL, err := discovery.Default.APIURL(host)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := tokenForHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Client{BaseURL: apiURL, Token: token}, nil
+}
+
+// getRegistryClient returns a client for the module registry protocol of
+// host.
+func getRegistryClient(ctx context.Context, host string) (*registry.Client, error) {
+	modulesURL, err := discovery.Default.ModulesURL(host)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := tokenForHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &registry.Client{BaseURL: modulesURL, Token: token}, nil
+}
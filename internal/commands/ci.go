@@ -0,0 +1,440 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/hcl/v2"
+	svchost "github.com/hashicorp/terraform-svchost"
+	"github.com/sethvargo/go-githubactions"
+
+	"github.com/registry-tools/rt-cli/internal/ci"
+	"github.com/registry-tools/rt-cli/internal/discovery"
+	"github.com/registry-tools/rt-cli/internal/lint"
+	"github.com/registry-tools/rt-cli/internal/oidc"
+	"github.com/registry-tools/rt-cli/internal/publish"
+	"github.com/registry-tools/rt-cli/internal/summarize"
+	sdk "github.com/registry-tools/rt-sdk"
+)
+
+// largeArchiveSize is the gzipped size above which a module archive is
+// reported as unusually large.
+const largeArchiveSize = 1_000_000
+
+func CIPublishCommandFactory() (cli.Command, error) {
+	return &ciPublishCommand{}, nil
+}
+
+type ciPublishCommand struct{}
+
+func (c *ciPublishCommand) Help() string {
+	return `
+Usage: rt ci publish
+
+  Publish a module from a CI pipeline. The CI system is detected from the
+  environment, and inputs, outputs and the job summary use its native
+  features:
+
+    GitHub Actions  Action inputs, step outputs and the step summary.
+    GitLab CI/CD    RT_* variables, and a dotenv report (RT_DOTENV_FILE,
+                    default "rt.env") for outputs.
+    Buildkite       RT_* variables, build meta-data ("rt-<output>") for
+                    outputs, and build annotations.
+    Other           RT_* variables, and name=value lines written to
+                    RT_OUTPUT_FILE or stdout for outputs.
+
+Inputs:
+
+  namespace   (Required) The namespace of the module, Ex: RT_NAMESPACE.
+  version     (Required) The version of the module, Ex: RT_VERSION.
+  module      The name of the module. Defaults to the name of the
+              repository, without its owner or group, Ex:
+              "terraform-aws-vpc".
+  system      The provider system associated with the module. Defaults to
+              "null".
+  directory   The directory containing the module. Defaults to ".".
+  host        The hostname of the registry. Defaults to
+              REGISTRY_TOOLS_HOSTNAME or "registrytools.cloud".
+  preview     GitHub Actions only. When "true", publish a pre-release of
+              the version for the pull request, Ex: "1.4.0-pr.123.abcdef1",
+              and comment on the pull request with its usage.
+  provenance  When "true", attach a SLSA provenance statement, recording
+              the source, workflow and archive digest, to the published
+              version.
+  sbom        When "true", attach a CycloneDX SBOM of the providers and
+              modules that the module depends on to the published version.
+
+Outputs:
+
+  source             The registry source address of the published module.
+  version            The published version.
+  module-version-id  The ID of the module version in the registry.
+  namespace          The namespace of the module.
+  name               The name of the module.
+  system             The provider system of the module.
+  archive-sha256     The SHA-256 digest of the published archive.
+  archive-size       The size of the published gzipped archive in bytes.
+  registry-url       The URL of the module version in the registry.
+  summary            All of the above as a JSON object.
+
+Print an attached SBOM with "rt sbom" and a provenance statement with
+"rt show --provenance".
+
+If the module has an rt.yaml file, it is checked with "rt lint", and findings
+are reported as annotations. The module is not published if any rule with the
+"error" severity fails.
+
+Credentials are read from REGISTRY_TOOLS_TOKEN, or REGISTRY_TOOLS_CLIENT_ID
+and REGISTRY_TOOLS_CLIENT_SECRET. On GitHub Actions, the workflow's OIDC
+token is used when no token is set.
+`
+}
+
+func (c *ciPublishCommand) Run(args []string) int {
+	provider := ci.DetectFromEnvironment()
+	log.Printf("[INFO] Publishing from %s", provider.Name())
+
+	return runCIPublish(context.Background(), provider)
+}
+
+func (c *ciPublishCommand) Synopsis() string {
+	return "Publish a module from a CI pipeline"
+}
+
+// ModuleArgsFromCI returns a ModuleArgs from the inputs of a CI provider. The
+// module name defaults to the name of the repository being built.
+func ModuleArgsFromCI(p ci.Provider) (*ModuleArgs, error) {
+	return moduleArgsFromCI(p, true)
+}
+
+func moduleArgsFromCI(p ci.Provider, requireVersion bool) (*ModuleArgs, error) {
+	moduleName := p.Input("module")
+	if moduleName == "" {
+		repository := p.Repository()
+		if repository == "" {
+			return nil, errors.New("module input is required")
+		}
+
+		// GitLab projects may be nested in subgroups, so only the last path
+		// segment names the repository.
+		moduleName = path.Base(repository)
+	}
+
+	system := p.Input("system")
+	if system == "" {
+		system = "null"
+	}
+
+	version := p.Input("version")
+	if version == "" && requireVersion {
+		return nil, errors.New("version input is required")
+	}
+
+	directory := p.Input("directory")
+	if directory == "" {
+		directory = "."
+	}
+
+	namespace := p.Input("namespace")
+	if namespace == "" {
+		return nil, errors.New("namespace input is required")
+	}
+
+	return &ModuleArgs{
+		Namespace: namespace,
+		Name:      moduleName,
+		System:    system,
+		Version:   version,
+		Directory: directory,
+		Host:      p.Input("host"),
+	}, nil
+}
+
+// tokenFromGitHubActions returns REGISTRY_TOOLS_TOKEN or, if that is not set
+// and the workflow has the `id-token: write` permission, a short-lived token
+// obtained by exchanging the workflow's OIDC token at the registry.
+func tokenFromGitHubActions(ctx context.Context, action *githubactions.Action, hostname string) (string, error) {
+	if envToken := os.Getenv("REGISTRY_TOOLS_TOKEN"); envToken != "" {
+		return envToken, nil
+	}
+
+	if !oidc.Available(action) {
+		return "", errors.New("REGISTRY_TOOLS_TOKEN must be set, or the workflow must have the `id-token: write` permission")
+	}
+
+	login, err := discovery.Default.Login(hostname)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("[INFO] Exchanging the workflow's OIDC token for a registry token")
+
+	return oidc.Exchanger{
+		Action:   action,
+		TokenURL: login.Host.TokenURL,
+		Audience: hostname,
+	}.Exchange(ctx)
+}
+
+// sdkFromGitHubActions returns an SDK client authenticated with the token
+// returned by tokenFromGitHubActions.
+func sdkFromGitHubActions(ctx context.Context, action *githubactions.Action, hostname string) (sdk.SDK, error) {
+	apiHost, err := sdkHost(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := tokenFromGitHubActions(ctx, action, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdk.NewSDKWithAccessToken(apiHost, token)
+}
+
+// sdkForCI returns an SDK client using the credentials available to the CI
+// provider.
+func sdkForCI(ctx context.Context, p ci.Provider, hostname string) (sdk.SDK, error) {
+	if gha, ok := p.(*ci.GitHubActions); ok {
+		return sdkFromGitHubActions(ctx, gha.Action, hostname)
+	}
+
+	return getSDKForHost(hostname)
+}
+
+// reportCIFailure adds a summary to the job explaining why publishing failed
+// and how to fix it.
+func reportCIFailure(p ci.Provider, failure summarize.Failure) {
+	html, err := failure.HTML()
+	if err != nil {
+		log.Printf("[ERROR] Failed to generate a failure summary: %s", err)
+		return
+	}
+
+	if err := p.AddSummary(html); err != nil {
+		log.Printf("[ERROR] Failed to add the failure summary: %s", err)
+	}
+}
+
+// annotateProblems reports each validation problem as an annotation on the
+// file and line where it was found.
+func annotateProblems(p ci.Provider, directory string, problems publish.Problems) {
+	for _, problem := range problems {
+		level := ci.LevelError
+		if problem.Severity == hcl.DiagWarning {
+			level = ci.LevelWarning
+		}
+
+		annotation := ci.Annotation{
+			Level:   level,
+			Title:   problem.Summary,
+			Message: problem.Detail,
+			Line:    problem.Line,
+		}
+		if annotation.Message == "" {
+			annotation.Message = problem.Summary
+			annotation.Title = ""
+		}
+		if problem.File != "" {
+			annotation.File = filepath.ToSlash(filepath.Join(directory, problem.File))
+		}
+
+		if err := p.Annotate(annotation); err != nil {
+			log.Printf("[ERROR] Failed to annotate %s: %s", problem.File, err)
+		}
+	}
+}
+
+// runCIPublish validates, packs and publishes the module described by the
+// provider's inputs and reports the result through the provider. Problems are
+// reported as annotations, and a failure summary is added to the job if the
+// module could not be published. It returns the exit status of the command.
+func runCIPublish(ctx context.Context, p ci.Provider) int {
+	started := time.Now()
+
+	var ma, err = ModuleArgsFromCI(p)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch required input arguments: %s", err)
+		_ = p.Annotate(ci.Annotation{Level: ci.LevelError, Title: "Invalid inputs", Message: err.Error()})
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StageInputs, Err: err})
+		return 1
+	}
+
+	pullRequest := 0
+	if p.Input("preview") == "true" {
+		pullRequest, err = applyPreviewVersion(p, ma)
+		if err != nil {
+			log.Printf("[ERROR] Failed to derive the preview version: %s", err)
+			_ = p.Annotate(ci.Annotation{Level: ci.LevelError, Title: "Invalid preview", Message: err.Error()})
+			reportCIFailure(p, summarize.Failure{Stage: summarize.StageInputs, Err: err})
+			return 1
+		}
+		log.Printf("[INFO] Publishing preview version %q for pull request #%d", ma.Version, pullRequest)
+	}
+
+	hostname, err := svchost.ForComparison(ma.Hostname())
+	if err != nil {
+		log.Printf("[ERROR] Failed to parse hostname: %s", err)
+		_ = p.Annotate(ci.Annotation{Level: ci.LevelError, Title: "Invalid hostname", Message: err.Error()})
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StageInputs, Err: err})
+		return 127
+	}
+
+	sdkclient, err := sdkForCI(ctx, p, hostname.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to create SDK client: %s", err)
+		_ = p.Annotate(ci.Annotation{Level: ci.LevelError, Title: "Authentication failed", Message: err.Error()})
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StageAuth, Err: err})
+		return 127
+	}
+
+	p.Group("Validate module")
+	var pf packFlags
+	options, err := pf.options(ma.Directory)
+	var problems publish.Problems
+	if err == nil {
+		problems, err = publish.ValidatePackage(ma.Directory, options)
+	}
+	if err == nil && !problems.HasErrors() {
+		var findings lint.Findings
+		findings, err = lintConfiguredModule(ma.Directory)
+		problems = append(problems, findings.Problems()...)
+	}
+	if err == nil {
+		annotateProblems(p, ma.Directory, problems)
+	}
+	p.EndGroup()
+	if err != nil {
+		log.Printf("[ERROR] Failed to validate directory %q: %s", ma.Directory, err)
+		_ = p.Annotate(ci.Annotation{Level: ci.LevelError, Title: "Validation failed", Message: err.Error()})
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StageValidate, Err: err})
+		return 2
+	}
+	if problems.HasErrors() {
+		log.Printf("[ERROR] Module in directory %q has errors", ma.Directory)
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StageValidate, Problems: problems})
+		return 2
+	}
+
+	// Pack the source directory into a temporary file
+	p.Group("Pack module")
+	path, _, err := publish.PackAsFile(ma.Directory, options)
+	p.EndGroup()
+	if err != nil {
+		log.Printf("[ERROR] Failed to pack directory %q: %s", ma.Directory, err)
+		_ = p.Annotate(ci.Annotation{Level: ci.LevelError, Title: "Pack failed", Message: err.Error()})
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StagePack, Err: err})
+		return 2
+	}
+	defer os.Remove(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("[ERROR] Failed to stat archive file: %s", err)
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StagePack, Err: err})
+		return 2
+	}
+	size := info.Size()
+
+	if size > largeArchiveSize {
+		_ = p.Annotate(ci.Annotation{
+			Level:   ci.LevelWarning,
+			Title:   "Large module archive",
+			Message: fmt.Sprintf("The size of this module, gzipped, was %s. Use a .terraformignore file to exclude files that aren't needed by the module.", summarize.HumanizeBytes(size)),
+		})
+	}
+
+	ma.Metadata.ArchiveSHA256, err = publish.FileSHA256(path)
+	if err != nil {
+		log.Printf("[ERROR] Failed to hash archive file: %s", err)
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StagePack, Err: err})
+		return 2
+	}
+
+	var bom []byte
+	if p.Input("sbom") == "true" {
+		bom, err = moduleSBOM(path, hostname, *ma)
+		if err != nil {
+			log.Printf("[ERROR] Failed to list the dependencies of the module: %s", err)
+			reportCIFailure(p, summarize.Failure{Stage: summarize.StagePack, Err: err})
+			return 2
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open archive file: %s", err)
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StagePack, Err: err})
+		return 2
+	}
+	defer file.Close()
+
+	p.Group("Upload module")
+	summary, err := publishModuleArchive(ctx, file, size, sdkclient, hostname.String(), *ma)
+	p.EndGroup()
+	if err != nil {
+		log.Printf("[ERROR] Failed to publish module: %s", err)
+		_ = p.Annotate(ci.Annotation{Level: ci.LevelError, Title: "Publish failed", Message: err.Error()})
+		reportCIFailure(p, summarize.Failure{Stage: summarize.StageUpload, Err: err})
+		return 1
+	}
+
+	outputs, err := summary.Outputs()
+	if err != nil {
+		log.Printf("[ERROR] Module was published successfully, but this program failed to generate the outputs: %s", err)
+	}
+	for _, output := range outputs {
+		if err := p.SetOutput(output.Name, output.Value); err != nil {
+			log.Printf("[ERROR] Module was published successfully, but this program failed to set the %q output: %s", output.Name, err)
+		}
+	}
+
+	html, err := summary.HTML()
+	if err != nil {
+		log.Printf("[ERROR] Module was published successfully, but this program failed to generate a summary: %s", err)
+	} else if err := p.AddSummary(html); err != nil {
+		log.Printf("[ERROR] Module was published successfully, but this program failed to add the summary: %s", err)
+	}
+
+	// The module is already published, so failing to attach the SBOM or the
+	// provenance statement is only worth a warning
+	withProvenance := p.Input("provenance") == "true"
+	if bom != nil || withProvenance {
+		client, err := apiClientForCI(ctx, p, hostname.String())
+		if err != nil {
+			log.Printf("[WARN] Module was published successfully, but this program failed to create an API client: %s", err)
+			_ = p.Annotate(ci.Annotation{Level: ci.LevelWarning, Title: "Attachments failed", Message: err.Error()})
+		} else {
+			if bom != nil {
+				if err := uploadSBOM(ctx, client, summary.Module.ID, bom); err != nil {
+					log.Printf("[WARN] Module was published successfully, but this program failed to attach its SBOM: %s", err)
+					_ = p.Annotate(ci.Annotation{Level: ci.LevelWarning, Title: "SBOM failed", Message: err.Error()})
+				}
+			}
+
+			if withProvenance {
+				statement := moduleProvenance(p.Build(), *ma, hostname, map[string]string{"directory": p.Input("directory")}, started)
+				if err := uploadProvenance(ctx, client, summary.Module.ID, statement); err != nil {
+					log.Printf("[WARN] Module was published successfully, but this program failed to attach its provenance: %s", err)
+					_ = p.Annotate(ci.Annotation{Level: ci.LevelWarning, Title: "Provenance failed", Message: err.Error()})
+				}
+			}
+		}
+	}
+
+	if pullRequest > 0 {
+		if err := commentPreview(ctx, p, pullRequest, ma.Module(), hostname); err != nil {
+			log.Printf("[ERROR] Module was published successfully, but this program failed to comment on the pull request: %s", err)
+			_ = p.Annotate(ci.Annotation{Level: ci.LevelWarning, Title: "Pull request comment failed", Message: err.Error()})
+		}
+	}
+
+	return 0
+}
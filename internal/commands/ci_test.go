@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/sethvargo/go-githubactions"
+
+	"github.com/registry-tools/rt-cli/internal/ci"
+	"github.com/registry-tools/rt-cli/internal/publish"
+)
+
+func TestModuleArgsFromCI(t *testing.T) {
+	env := map[string]string{
+		"GITLAB_CI":       "true",
+		"CI_PROJECT_PATH": "platform/terraform-aws-vpc",
+		"RT_NAMESPACE":    "platform",
+		"RT_VERSION":      "1.2.3",
+		"RT_SYSTEM":       "aws",
+		"RT_HOST":         "registry.example.com",
+	}
+
+	ma, err := ModuleArgsFromCI(ci.Detect(func(k string) string { return env[k] }))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := ModuleArgs{
+		Namespace: "platform",
+		Name:      "terraform-aws-vpc",
+		System:    "aws",
+		Version:   "1.2.3",
+		Directory: ".",
+		Host:      "registry.example.com",
+	}
+	if *ma != expected {
+		t.Errorf("expected %+v, got %+v", expected, *ma)
+	}
+
+	if ma.Hostname() != "registry.example.com" {
+		t.Errorf("expected the host input to be used, got %q", ma.Hostname())
+	}
+
+	delete(env, "RT_SYSTEM")
+	env["CI_PROJECT_PATH"] = "platform/networking/terraform-aws-vpc"
+	ma, err = ModuleArgsFromCI(ci.Detect(func(k string) string { return env[k] }))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if ma.Name != "terraform-aws-vpc" || ma.System != "null" {
+		t.Errorf("expected terraform-aws-vpc/null from a subgroup project, got %s/%s", ma.Name, ma.System)
+	}
+
+	delete(env, "RT_VERSION")
+	if _, err := ModuleArgsFromCI(ci.Detect(func(k string) string { return env[k] })); err == nil {
+		t.Error("expected an error when the version input is missing")
+	}
+
+	github := map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_REPOSITORY": "org/terraform-aws-vpc",
+		"INPUT_NAMESPACE":   "platform",
+		"INPUT_VERSION":     "1.2.3",
+	}
+	ma, err = ModuleArgsFromCI(ci.Detect(func(k string) string { return github[k] }))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if ma.Name != "terraform-aws-vpc" || ma.System != "null" {
+		t.Errorf("expected terraform-aws-vpc/null on GitHub Actions, got %s/%s", ma.Name, ma.System)
+	}
+}
+
+func TestAnnotateProblems(t *testing.T) {
+	var stdout bytes.Buffer
+	p := &ci.GitHubActions{Action: githubactions.New(githubactions.WithGetenv(func(string) string { return "" }), githubactions.WithWriter(&stdout))}
+
+	annotateProblems(p, "modules/vpc", publish.Problems{
+		{Severity: hcl.DiagError, Summary: "Unclosed configuration block", Detail: "There is no closing brace.", File: "main.tf", Line: 3},
+		{Severity: hcl.DiagWarning, Summary: "Deprecated syntax", File: "outputs.tf", Line: 1},
+	})
+
+	expected := "::error file=modules/vpc/main.tf,line=3,title=Unclosed configuration block::There is no closing brace.\n" +
+		"::warning file=modules/vpc/outputs.tf,line=1::Deprecated syntax\n"
+	if stdout.String() != expected {
+		t.Errorf("unexpected annotations %q", stdout.String())
+	}
+}
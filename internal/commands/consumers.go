@@ -0,0 +1,359 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/go-version"
+
+	"github.com/registry-tools/rt-cli/internal/consumers"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/registry"
+)
+
+func ConsumersUpdateCommandFactory() (cli.Command, error) {
+	return &consumersUpdateCommand{}, nil
+}
+
+func ConsumersFindCommandFactory() (cli.Command, error) {
+	return &consumersFindCommand{}, nil
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, and returns the positional arguments.
+func parseInterspersed(f *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := f.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = f.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+type consumersUpdateCommand struct{}
+
+func (c *consumersUpdateCommand) Help() string {
+	return `
+Usage: rt consumers update <source> --version=<version> [options] [paths...]
+
+  Update the version constraint of every module block that calls a module,
+  Ex: "registrytools.cloud/platform/vpc/aws", in the Terraform files under
+  the specified paths. Paths default to the current directory, and may be
+  local checkouts of the repositories that consume the module.
+
+  Module blocks without a version argument are left unchanged. The rest of
+  each file, including comments and alignment, is preserved. Files that
+  cannot be parsed are skipped and listed on stderr.
+
+  The version argument is replaced with the constraint as given, so a range
+  such as "~> 1.2" becomes an exact version unless the constraint is a range
+  too, Ex: --version="~> 1.4".
+
+Options:
+
+  --version=<version>  (Required) The version constraint to set, Ex: "1.4.0"
+                       or "~> 1.4".
+
+  --dry-run            Report the module blocks that would change without
+                       writing any files.
+`
+}
+
+func (c *consumersUpdateCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var version string
+	var dryRun bool
+	f.StringVar(&version, "version", "", "")
+	f.BoolVar(&dryRun, "dry-run", false, "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if len(positional) == 0 {
+		log.Printf("[ERROR] Required argument %q is missing", "source")
+		return 1
+	}
+	if version == "" {
+		log.Printf("[ERROR] Required argument %q is missing", "version")
+		return 1
+	}
+
+	source, paths := positional[0], positional[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	changes, skipped, err := consumers.Update(paths, source, version, !dryRun)
+	printSkipped(os.Stderr, skipped)
+	for _, change := range changes {
+		fmt.Printf("%s:%d: module.%s %q -> %q\n", change.File, change.Line, change.Name, change.Version, change.NewVersion)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to update consumers: %s", err)
+		return 1
+	}
+
+	summary := color.New(color.FgCyan, color.Faint)
+	switch {
+	case len(changes) == 0:
+		summary.Printf("No module blocks needed to be updated to %s\n", version)
+	case dryRun:
+		summary.Printf("%d module block(s) would be updated to %s\n", len(changes), version)
+	default:
+		summary.Printf("Updated %d module block(s) to %s\n", len(changes), version)
+	}
+	if len(skipped) > 0 {
+		summary.Printf("%d file(s) could not be parsed and were not updated\n", len(skipped))
+	}
+
+	return 0
+}
+
+// printSkipped lists the files that were skipped because they could not be
+// parsed. They are written to w rather than logged, because warnings are not
+// logged by default.
+func printSkipped(w io.Writer, skipped []*consumers.ParseError) {
+	warning := color.New(color.FgHiYellow, color.Bold)
+	for _, parseErr := range skipped {
+		// The diagnostics usually start with the file name already
+		msg := parseErr.Diags.Error()
+		if !strings.HasPrefix(msg, parseErr.File) {
+			msg = parseErr.File + ": " + msg
+		}
+		warning.Fprint(w, "Skipped ")
+		fmt.Fprintln(w, msg)
+	}
+}
+
+func (c *consumersUpdateCommand) Synopsis() string {
+	return "Update the version of a module in the configurations that use it"
+}
+
+type consumersFindCommand struct{}
+
+func (c *consumersFindCommand) Help() string {
+	return `
+Usage: rt consumers find <source> [options] [paths...]
+
+  Find every module block that calls a module, Ex:
+  "registrytools.cloud/platform/vpc/aws", in the Terraform files under the
+  specified paths, and report the version constraint each one uses and
+  whether it allows the latest published version. Paths default to the
+  current directory. Files that cannot be parsed are skipped and listed on
+  stderr. When it cannot be checked whether a constraint allows the latest
+  version, the table shows why and the JSON output has an "error" field.
+
+Options:
+
+  --format=<format>  The output format, "table" or "json". Defaults to
+                     "table".
+
+  --offline          Do not look up the latest published version.
+`
+}
+
+// consumerResult is a module block found by `rt consumers find`.
+type consumerResult struct {
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Name       string `json:"name"`
+	Source     string `json:"source"`
+	Constraint string `json:"constraint"`
+	Latest     string `json:"latest,omitempty"`
+	// AllowsLatest is nil when the latest version is unknown, or when Error
+	// explains why it could not be checked.
+	AllowsLatest *bool  `json:"allows-latest,omitempty"`
+	Error        string `json:"error,omitempty"`
+
+	// reason is a short form of Error for the table.
+	reason string
+}
+
+// latestModuleVersion returns the latest published version of the module at
+// source.
+func latestModuleVersion(ctx context.Context, source string) (*version.Version, error) {
+	host, mod, err := module.ParseSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := getRegistryClient(ctx, host.String())
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := client.ModuleVersions(ctx, mod.Namespace, mod.Name, mod.System)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := registry.Latest(versions)
+	if latest == nil {
+		return nil, errors.New("no stable versions have been published")
+	}
+	return latest, nil
+}
+
+// consumerResults checks whether each call allows latest. If the latest
+// version is unknown because looking it up failed, latestErr is recorded
+// as the error of each result.
+func consumerResults(calls []consumers.Call, latest *version.Version, latestErr error) []consumerResult {
+	results := make([]consumerResult, 0, len(calls))
+	for _, call := range calls {
+		result := consumerResult{
+			File:       call.File,
+			Line:       call.Line,
+			Name:       call.Name,
+			Source:     call.Source,
+			Constraint: call.Version,
+		}
+
+		switch {
+		case latest != nil:
+			result.Latest = latest.String()
+			if allows, err := call.Allows(latest); err != nil {
+				result.Error = err.Error()
+				result.reason = "invalid constraint"
+			} else {
+				result.AllowsLatest = &allows
+			}
+		case latestErr != nil:
+			result.Error = fmt.Sprintf("failed to look up the latest version: %s", latestErr)
+			result.reason = "lookup failed"
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+func (c *consumersFindCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var format string
+	var offline bool
+	f.StringVar(&format, "format", "table", "")
+	f.BoolVar(&offline, "offline", false, "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if len(positional) == 0 {
+		log.Printf("[ERROR] Required argument %q is missing", "source")
+		return 1
+	}
+	if format != "table" && format != "json" {
+		log.Printf("[ERROR] Unsupported format %q, expected \"table\" or \"json\"", format)
+		return 1
+	}
+
+	source, paths := positional[0], positional[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	if _, _, err := module.ParseSource(source); err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	calls, skipped, err := consumers.Find(paths, source)
+	if err != nil {
+		log.Printf("[ERROR] Failed to find consumers: %s", err)
+		return 1
+	}
+	printSkipped(os.Stderr, skipped)
+
+	// Problems are written to stderr rather than logged, because warnings are
+	// not logged by default and the results would look complete without them
+	var latest *version.Version
+	var latestErr error
+	if !offline && len(calls) > 0 {
+		latest, latestErr = latestModuleVersion(context.Background(), source)
+		if latestErr != nil {
+			color.New(color.FgHiYellow, color.Bold).Fprint(os.Stderr, "Warning: ")
+			fmt.Fprintf(os.Stderr, "Failed to look up the latest version of %s: %s\n", source, latestErr)
+		}
+	}
+
+	results := consumerResults(calls, latest, latestErr)
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(results); err != nil {
+			log.Printf("[ERROR] Failed to encode results: %s", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(results) == 0 {
+		color.New(color.FgCyan, color.Faint).Printf("No module blocks call %s\n", source)
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tMODULE\tCONSTRAINT\tALLOWS LATEST")
+	for _, result := range results {
+		constraint := result.Constraint
+		if constraint == "" {
+			constraint = "(any)"
+		}
+
+		allows := "unknown"
+		if result.reason != "" {
+			allows = fmt.Sprintf("unknown (%s)", result.reason)
+		}
+		if result.AllowsLatest != nil {
+			allows = "no"
+			if *result.AllowsLatest {
+				allows = "yes"
+			}
+			allows = fmt.Sprintf("%s (%s)", allows, result.Latest)
+		}
+
+		fmt.Fprintf(w, "%s:%d\tmodule.%s\t%s\t%s\n", result.File, result.Line, result.Name, constraint, allows)
+	}
+	w.Flush()
+
+	return 0
+}
+
+func (c *consumersFindCommand) Synopsis() string {
+	return "Find the configurations that use a module"
+}
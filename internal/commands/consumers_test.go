@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/registry-tools/rt-cli/internal/consumers"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+
+	var version string
+	var dryRun bool
+	f.StringVar(&version, "version", "", "")
+	f.BoolVar(&dryRun, "dry-run", false, "")
+
+	positional, err := parseInterspersed(f, []string{"example.com/org/vpc/aws", "--version=1.4.0", "../a", "--dry-run", "../b", "--", "--c"})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if strings.Join(positional, " ") != "example.com/org/vpc/aws ../a ../b --c" {
+		t.Errorf("unexpected positional arguments %q", positional)
+	}
+	if version != "1.4.0" || !dryRun {
+		t.Errorf("unexpected flags %q %v", version, dryRun)
+	}
+}
+
+func TestPrintSkipped(t *testing.T) {
+	skipped := []*consumers.ParseError{{
+		File: "broken/main.tf",
+		Diags: hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unclosed configuration block",
+		}},
+	}}
+
+	var out bytes.Buffer
+	printSkipped(&out, skipped)
+	if !strings.Contains(out.String(), "Skipped broken/main.tf: ") || !strings.Contains(out.String(), "Unclosed configuration block") {
+		t.Errorf("expected the skipped file and its error, got %q", out.String())
+	}
+}
+
+func TestConsumerResults(t *testing.T) {
+	calls := []consumers.Call{
+		{File: "main.tf", Line: 1, Name: "ok", Version: "~> 1.2"},
+		{File: "main.tf", Line: 5, Name: "bad", Version: "not a constraint"},
+	}
+
+	results := consumerResults(calls, version.Must(version.NewVersion("1.4.0")), nil)
+	if results[0].AllowsLatest == nil || !*results[0].AllowsLatest || results[0].Error != "" {
+		t.Errorf("expected ~> 1.2 to allow 1.4.0, got %+v", results[0])
+	}
+	if results[1].AllowsLatest != nil || results[1].Error == "" || results[1].reason != "invalid constraint" {
+		t.Errorf("expected an error for the invalid constraint, got %+v", results[1])
+	}
+
+	results = consumerResults(calls, nil, errors.New("module not found"))
+	for _, result := range results {
+		if result.AllowsLatest != nil || !strings.Contains(result.Error, "module not found") || result.reason != "lookup failed" {
+			t.Errorf("expected the lookup error to be recorded, got %+v", result)
+		}
+	}
+
+	results = consumerResults(calls, nil, nil)
+	for _, result := range results {
+		if result.Error != "" || result.reason != "" {
+			t.Errorf("expected no error when offline, got %+v", result)
+		}
+	}
+}
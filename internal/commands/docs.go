@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+
+	"github.com/registry-tools/rt-cli/internal/docs"
+)
+
+func DocsCommandFactory() (cli.Command, error) {
+	return &docsCommand{}, nil
+}
+
+type docsCommand struct{}
+
+func (c *docsCommand) Help() string {
+	return `
+Usage: rt docs [options] [dir]
+
+  Generate Markdown documentation of the module in dir, which defaults to the
+  current directory: its requirements, submodules, resources, inputs and
+  outputs. By default, the documentation is printed.
+
+Options:
+
+  --inject   Write the documentation into README.md, replacing the content
+             between the <!-- BEGIN_RT_DOCS --> and <!-- END_RT_DOCS -->
+             comments. Without the comments, it is appended to README.md.
+
+  --check    Exit with status 1 if the documentation in README.md is not up
+             to date, Ex: to fail a CI job.
+`
+}
+
+func (c *docsCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var inject, check bool
+	f.BoolVar(&inject, "inject", false, "")
+	f.BoolVar(&check, "check", false, "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	dir := "."
+	switch len(positional) {
+	case 0:
+	case 1:
+		dir = positional[0]
+	default:
+		log.Printf("[ERROR] Expected at most one module directory")
+		return 1
+	}
+
+	if inject && check {
+		log.Printf("[ERROR] --inject and --check cannot be used together")
+		return 1
+	}
+
+	if !inject && !check {
+		m, err := docs.Load(dir)
+		if err != nil {
+			log.Printf("[ERROR] Failed to read module in %q: %s", dir, err)
+			return 1
+		}
+		fmt.Print(m.Markdown())
+		return 0
+	}
+
+	current, updated, err := docs.Readme(dir)
+	if err != nil {
+		log.Printf("[ERROR] Failed to generate documentation for %q: %s", dir, err)
+		return 1
+	}
+
+	readme := filepath.Join(dir, docs.ReadmeName)
+
+	if check {
+		if current != updated {
+			log.Printf("[ERROR] The documentation in %s is out of date. Run \"rt docs --inject\" to update it.", readme)
+			return 1
+		}
+		color.New(color.FgGreen).Printf("The documentation in %s is up to date\n", readme)
+		return 0
+	}
+
+	if current == updated {
+		color.New(color.FgCyan, color.Faint).Printf("The documentation in %s is already up to date\n", readme)
+		return 0
+	}
+
+	if err := os.WriteFile(readme, []byte(updated), 0644); err != nil {
+		log.Printf("[ERROR] Failed to write %s: %s", readme, err)
+		return 1
+	}
+	color.New(color.FgGreen).Printf("Updated the documentation in %s\n", readme)
+	return 0
+}
+
+func (c *docsCommand) Synopsis() string {
+	return "Generate documentation of a module's inputs and outputs"
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/fatih/color"
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/examples"
+	"github.com/registry-tools/rt-cli/internal/module"
+)
+
+// testModuleExamples runs terraform against the examples of the module
+// described by ma, and prints the results. It returns false if any example
+// failed or the examples could not be tested. If terraformPath is empty and
+// terraform is not installed, the examples are skipped.
+func testModuleExamples(ctx context.Context, host svchost.Hostname, ma ModuleArgs, terraformPath string) bool {
+	info := color.New(color.FgCyan, color.Faint)
+
+	runner, err := examples.NewRunner(terraformPath)
+	if errors.Is(err, examples.ErrTerraformNotFound) && terraformPath == "" {
+		color.New(color.FgHiYellow, color.Bold).Println("Skipping example tests: terraform is not installed. Install it, or set its path with --terraform-path.")
+		return true
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to test examples: %s", err)
+		return false
+	}
+
+	mod := module.Module{Namespace: ma.Namespace, Name: ma.Name, System: ma.System}
+	results, err := runner.Test(ctx, ma.Directory, host, mod)
+	if err != nil {
+		log.Printf("[ERROR] Failed to test examples: %s", err)
+		return false
+	}
+	if len(results) == 0 {
+		info.Println("No examples to test")
+		return true
+	}
+
+	printExampleResults(results)
+
+	if results.Failed() {
+		log.Printf("[ERROR] One or more examples failed; the module was not published")
+		return false
+	}
+	return true
+}
+
+func printExampleResults(results examples.Results) {
+	label := color.New(color.FgCyan, color.Faint)
+	ok := color.New(color.FgGreen)
+	failed := color.New(color.FgRed, color.Bold)
+
+	for _, result := range results {
+		label.Printf("%-30s %-9s", result.Example, result.Step)
+		if result.Err == nil {
+			ok.Println("ok")
+			continue
+		}
+
+		failed.Println("failed")
+		output := strings.TrimSpace(result.Output)
+		if output == "" {
+			output = result.Err.Error()
+		}
+		for _, line := range strings.Split(output, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+
+	"github.com/registry-tools/rt-cli/internal/lint"
+	"github.com/registry-tools/rt-cli/internal/moduleconfig"
+)
+
+func LintCommandFactory() (cli.Command, error) {
+	return &lintCommand{}, nil
+}
+
+type lintCommand struct{}
+
+func (c *lintCommand) Help() string {
+	return `
+Usage: rt lint [options] [dir]
+
+  Check the module in dir, which defaults to the current directory, against
+  rules for module quality. Exits with status 1 if any rule with the "error"
+  severity fails.
+
+  Rules:
+
+    variable-description    Every variable has a description (warning)
+    variable-type           Every variable has a type (warning)
+    output-description      Every output has a description (warning)
+    provider-configuration  Modules do not configure providers (error)
+    required-version        required_version sets a lower bound (error)
+    required-providers      Every provider is in required_providers, with a
+                            source and a lower bound on its version (error)
+    backend                 Modules do not configure a backend or HCP
+                            Terraform (error)
+    examples-valid          Every example in the examples directory can be
+                            parsed (error)
+
+  The severity of each rule can be changed to error, warning, notice or off
+  in the module's rt.yaml:
+
+    lint:
+      rules:
+        variable-type: error
+        backend: off
+
+  A finding is ignored if the line it is on, or the line before it, has a
+  comment like "# rt-lint-ignore: variable-type, variable-description".
+
+Options:
+
+  --format=<format>  The output format: "text" (the default) or "sarif", for
+                     uploading to code scanning, Ex: GitHub's.
+
+  --output=<file>    Write the output to a file instead of stdout.
+`
+}
+
+func (c *lintCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var format, output string
+	f.StringVar(&format, "format", "text", "")
+	f.StringVar(&output, "output", "", "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	dir := "."
+	switch len(positional) {
+	case 0:
+	case 1:
+		dir = positional[0]
+	default:
+		log.Printf("[ERROR] Expected at most one module directory")
+		return 1
+	}
+
+	if format != "text" && format != "sarif" {
+		log.Printf("[ERROR] Unknown format %q; expected text or sarif", format)
+		return 1
+	}
+
+	findings, err := lintModule(dir)
+	if err != nil {
+		log.Printf("[ERROR] Failed to lint module in %q: %s", dir, err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			log.Printf("[ERROR] Failed to create %s: %s", output, err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if format == "sarif" {
+		data, err := findings.SARIF(filepath.ToSlash(filepath.Clean(dir)))
+		if err != nil {
+			log.Printf("[ERROR] Failed to encode SARIF: %s", err)
+			return 1
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			log.Printf("[ERROR] Failed to write SARIF: %s", err)
+			return 1
+		}
+	} else {
+		printFindings(w, dir, findings)
+	}
+
+	if findings.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+func (c *lintCommand) Synopsis() string {
+	return "Check a module against rules for module quality"
+}
+
+// lintModule lints the module in dir with the rule severities configured in
+// its rt.yaml.
+func lintModule(dir string) (lint.Findings, error) {
+	config, err := moduleconfig.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	return lint.Lint(dir, config.Lint)
+}
+
+func printFindings(w io.Writer, dir string, findings lint.Findings) {
+	levels := map[lint.Severity]*color.Color{
+		lint.SeverityError:   color.New(color.FgRed, color.Bold),
+		lint.SeverityWarning: color.New(color.FgHiYellow, color.Bold),
+		lint.SeverityNotice:  color.New(color.FgCyan),
+	}
+	location := color.New(color.FgCyan, color.Faint)
+
+	errors, warnings := 0, 0
+	for _, finding := range findings {
+		switch finding.Severity {
+		case lint.SeverityError:
+			errors++
+		case lint.SeverityWarning:
+			warnings++
+		}
+
+		if finding.File != "" {
+			location.Fprintf(w, "%s:%d: ", filepath.Join(dir, finding.File), finding.Line)
+		}
+		levels[finding.Severity].Fprintf(w, "%-8s", finding.Severity)
+		fmt.Fprintf(w, "%s ", finding.Message)
+		location.Fprintf(w, "[%s]\n", finding.Rule)
+	}
+
+	if len(findings) == 0 {
+		color.New(color.FgGreen).Fprintf(w, "No problems found in %s\n", dir)
+		return
+	}
+	fmt.Fprintf(w, "\n%d problem(s): %d error(s), %d warning(s)\n", len(findings), errors, warnings)
+}
+
+// lintConfiguredModule lints the module in dir if it has an rt.yaml, so that
+// modules which opt in to linting cannot be published with errors. It returns
+// no findings if the module has no configuration file.
+func lintConfiguredModule(dir string) (lint.Findings, error) {
+	config, err := moduleconfig.Load(dir)
+	if err != nil || config.Path == "" {
+		return nil, err
+	}
+	return lint.Lint(dir, config.Lint)
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLintConfiguredModule(t *testing.T) {
+	dir := t.TempDir()
+	main := `terraform {
+  required_version = ">= 1.5"
+  backend "s3" {}
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := lintConfiguredModule(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("expected modules without rt.yaml not to be linted, got %v", findings)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "rt.yaml"), []byte("lint: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	findings, err = lintConfiguredModule(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !findings.HasErrors() {
+		t.Errorf("expected the backend to be an error, got %v", findings)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "rt.yaml"), []byte("lint:\n  rules:\n    backend: off\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	findings, err = lintConfiguredModule(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected no findings with the backend rule off, got %v", findings)
+	}
+}
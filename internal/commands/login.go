@@ -2,14 +2,24 @@ package commands
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"strings"
 
+	"github.com/cli/browser"
 	"github.com/cli/oauth"
+	oauthapi "github.com/cli/oauth/api"
 	"github.com/fatih/color"
 	"github.com/hashicorp/cli"
 
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/auth"
+	"github.com/registry-tools/rt-cli/internal/discovery"
 	userconfig "github.com/registry-tools/rt-cli/internal/userconfig"
 	"github.com/registry-tools/rt-cli/version"
 )
@@ -20,67 +30,195 @@ func LoginCommandFactory() (cli.Command, error) {
 
 type loginCommand struct{}
 
+// loginPrompt controls how the login flow interacts with the user.
+type loginPrompt int
+
+const (
+	// promptEnter waits for the user to press Enter before opening the browser.
+	promptEnter loginPrompt = iota
+	// promptBrowser opens the browser without waiting for input.
+	promptBrowser
+	// promptNone prints the code and URL and never opens a browser or reads input.
+	promptNone
+)
+
 func (c *loginCommand) Help() string {
 	return `
-Usage: rt login [hostname]
+Usage: rt login [options] [hostname]
 
   Login to a Registry Tools private registry. Optionally, provide a hostname
-	to login to. If none is given, defaults to "registrytools.cloud".
+  to login to. If none is given, defaults to "registrytools.cloud".
+
+Options:
+
+  --browser      Open the verification URL in the web browser automatically,
+                 without waiting for [Enter] to be pressed.
+
+  --no-prompt    Do not read from stdin or open a web browser. The one-time
+                 code and verification URL are printed and the CLI waits for
+                 the login to be completed elsewhere. Useful over SSH or in
+                 other headless environments.
+
+  --pkce         Use the authorization code flow with PKCE, receiving the
+                 response on a loopback address, instead of the device flow.
+                 The browser must run on the same machine as the CLI.
+
+  --with-token   Read a token from stdin instead of logging in interactively.
+                 The token is validated against the registry before it is
+                 stored. Ex: "rt login --with-token < token.txt".
 `
 }
 
+// newDeviceFlow returns a device authorization flow that interacts with the
+// user as specified by prompt.
+func newDeviceFlow(host *oauth.Host, prompt loginPrompt, stdin io.Reader, stdout io.Writer) *oauth.Flow {
+	colorWarn := color.New(color.FgHiYellow, color.Bold)
+
+	flow := &oauth.Flow{
+		Host:     host,
+		Scopes:   []string{"owner"},
+		ClientID: "rt-cli",
+		Stdin:    stdin,
+		Stdout:   stdout,
+		DisplayCode: func(code string, url string) error {
+			fmt.Fprint(stdout, "First, copy your one-time code: ")
+			colorWarn.Fprintf(stdout, "%s\n\n", code)
+
+			switch prompt {
+			case promptNone:
+				fmt.Fprintf(stdout, "Then visit %s to continue. Waiting for login to complete...\n", url)
+			case promptBrowser:
+				fmt.Fprintf(stdout, "Opening %s in your web browser...\n", url)
+			default:
+				fmt.Fprintf(stdout, "Press [Enter] to continue in the web browser... ")
+
+				scanner := bufio.NewScanner(stdin)
+				scanner.Scan()
+			}
+
+			return nil
+		},
+	}
+
+	if prompt == promptNone {
+		flow.BrowseURL = func(string) error { return nil }
+	}
+
+	return flow
+}
+
+// newPKCEFlow returns an authorization code flow with PKCE that opens the
+// browser unless prompt is promptNone, in which case the URL is only printed.
+func newPKCEFlow(login *discovery.Login, prompt loginPrompt, stdout io.Writer) auth.PKCEFlow {
+	return auth.PKCEFlow{
+		Host:     &login.Host,
+		Scopes:   []string{"owner"},
+		ClientID: "rt-cli",
+		MinPort:  login.MinPort,
+		MaxPort:  login.MaxPort,
+		BrowseURL: func(url string) error {
+			if prompt == promptNone {
+				fmt.Fprintf(stdout, "Visit this URL in a browser on this machine to continue:\n\n  %s\n\nWaiting for login to complete...\n", url)
+				return nil
+			}
+
+			fmt.Fprintf(stdout, "Opening %s in your web browser...\n", url)
+			return browser.OpenURL(url)
+		},
+	}
+}
+
 func (c *loginCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var openBrowser, noPrompt, usePKCE, withToken bool
+	f.BoolVar(&openBrowser, "browser", false, "")
+	f.BoolVar(&noPrompt, "no-prompt", false, "")
+	f.BoolVar(&usePKCE, "pkce", false, "")
+	f.BoolVar(&withToken, "with-token", false, "")
+
+	colorErr := color.New(color.FgRed, color.Bold)
+	colorSuccess := color.New(color.FgCyan, color.Faint)
+
+	if err := f.Parse(args); err != nil {
+		colorErr.Printf("Login failed: %s\n", err)
+		return 1
+	}
+
+	if openBrowser && noPrompt {
+		colorErr.Println("Login failed: --browser and --no-prompt cannot be used together")
+		return 1
+	}
+
 	hostname := DefaultHostname
-	if len(args) == 1 {
-		hostname = args[0]
+	if f.NArg() == 1 {
+		hostname = f.Arg(0)
 	}
 
 	hostname = strings.TrimPrefix(hostname, "https://")
 	hostname = strings.TrimPrefix(hostname, "http://")
 
-	host := oauth.Host{
-		DeviceCodeURL: "https://" + hostname + "/auth/device/code",
-		TokenURL:      "https://" + hostname + "/auth/token",
-		AuthorizeURL:  "https://" + hostname + "/login",
-	}
+	if withToken {
+		if openBrowser || noPrompt || usePKCE {
+			colorErr.Println("Login failed: --with-token cannot be combined with other login options")
+			return 1
+		}
 
-	colorWarn := color.New(color.FgHiYellow, color.Bold)
-	colorErr := color.New(color.FgRed, color.Bold)
-	colorSuccess := color.New(color.FgCyan, color.Faint)
+		token, err := readTokenFromStdin(os.Stdin)
+		if err != nil {
+			colorErr.Printf("Login failed: %s\n", err)
+			return 1
+		}
 
-	colorSuccess.Printf("Logging in to %s...\n", hostname)
+		apiURL, err := discovery.Default.APIURL(hostname)
+		if err != nil {
+			colorErr.Printf("Login failed: %s\n", err)
+			return 1
+		}
 
-	flow := oauth.Flow{
-		Host:     &host,
-		Scopes:   []string{"owner"},
-		ClientID: "rt-cli",
-		DisplayCode: func(code string, url string) error {
-			fmt.Print("First, copy your one-time code: ")
-			colorWarn.Printf("%s\n\n", code)
-			fmt.Printf("Press [Enter] to continue in the web browser... ")
+		if err := validateToken(context.Background(), &api.Client{BaseURL: apiURL, Token: token}); err != nil {
+			colorErr.Printf("Login failed: %s\n", err)
+			return 1
+		}
 
-			scanner := bufio.NewScanner(os.Stdin)
-			scanner.Scan()
+		if err := saveHostToken(hostname, token); err != nil {
+			colorErr.Printf("Login failed: %s\n", err)
+			return 1
+		}
 
-			return nil
-		},
+		colorSuccess.Printf("Stored token for %s\n", hostname)
+		return 0
 	}
 
-	accessToken, err := flow.DeviceFlow()
+	login, err := discovery.Default.Login(hostname)
 	if err != nil {
 		colorErr.Printf("Login failed: %s\n", err)
 		return 1
 	}
 
-	config, err := userconfig.LoadFromUserConfigDirectory()
+	prompt := promptEnter
+	if openBrowser {
+		prompt = promptBrowser
+	} else if noPrompt {
+		prompt = promptNone
+	}
+
+	colorSuccess.Printf("Logging in to %s...\n", hostname)
+
+	var accessToken *oauthapi.AccessToken
+	if usePKCE {
+		accessToken, err = newPKCEFlow(login, prompt, os.Stdout).Run(context.Background())
+	} else {
+		accessToken, err = newDeviceFlow(&login.Host, prompt, os.Stdin, os.Stdout).DeviceFlow()
+	}
 	if err != nil {
 		colorErr.Printf("Login failed: %s\n", err)
 		return 1
 	}
 
-	config.SetHostToken(hostname, accessToken.Token)
-
-	if err = config.SaveToUserConfigDirectory(version.Version); err != nil {
+	if err := saveHostToken(hostname, accessToken.Token); err != nil {
 		colorErr.Printf("Login failed: %s\n", err)
 		return 1
 	}
@@ -88,6 +226,48 @@ func (c *loginCommand) Run(args []string) int {
 	return 0
 }
 
+// readTokenFromStdin reads a single token from r, ignoring surrounding
+// whitespace.
+func readTokenFromStdin(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token from stdin: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New("no token was provided on stdin")
+	}
+
+	if strings.ContainsAny(token, " \t\r\n") {
+		return "", errors.New("expected a single token on stdin")
+	}
+
+	return token, nil
+}
+
+// validateToken confirms that the registry accepts the client's token.
+func validateToken(ctx context.Context, client *api.Client) error {
+	account, err := client.Whoami(ctx)
+	if err != nil {
+		return fmt.Errorf("token could not be validated: %w", err)
+	}
+
+	log.Printf("[DEBUG] Token authenticates as %s %q", account.Type, account.Name)
+	return nil
+}
+
+func saveHostToken(hostname, token string) error {
+	config, err := userconfig.LoadFromUserConfigDirectory()
+	if err != nil {
+		return err
+	}
+
+	config.SetHostToken(hostname, token)
+
+	return config.SaveToUserConfigDirectory(version.Version)
+}
+
 func (c *loginCommand) Synopsis() string {
-	return "Publish a module to the registry"
+	return "Login to a Registry Tools registry"
 }
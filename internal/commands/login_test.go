@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cli/oauth"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+)
+
+type failingReader struct {
+	t *testing.T
+}
+
+func (r failingReader) Read(p []byte) (int, error) {
+	r.t.Error("expected stdin not to be read")
+	return 0, nil
+}
+
+func newTestDeviceServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	polls := 0
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/auth/device/code", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(`{"device_code":"dc-1","user_code":"ABCD-1234","verification_uri":"https://example.com/activate","expires_in":60,"interval":0}`))
+	})
+
+	mux.HandleFunc("/auth/token", func(res http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %s", err)
+		}
+		if req.PostForm.Get("device_code") != "dc-1" {
+			t.Errorf("expected device_code dc-1, got %q", req.PostForm.Get("device_code"))
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		polls++
+		if polls == 1 {
+			_, _ = res.Write([]byte(`{"error":"authorization_pending"}`))
+			return
+		}
+		_, _ = res.Write([]byte(`{"access_token":"device-token","token_type":"bearer"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDeviceFlowNoPrompt(t *testing.T) {
+	server := newTestDeviceServer(t)
+
+	var stdout bytes.Buffer
+	flow := newDeviceFlow(&oauth.Host{
+		DeviceCodeURL: server.URL + "/auth/device/code",
+		TokenURL:      server.URL + "/auth/token",
+	}, promptNone, failingReader{t}, &stdout)
+
+	token, err := flow.DeviceFlow()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if token.Token != "device-token" {
+		t.Errorf("expected token %q, got %q", "device-token", token.Token)
+	}
+
+	output := stdout.String()
+	if !strings.Contains(output, "ABCD-1234") {
+		t.Errorf("expected output to contain the user code, got %q", output)
+	}
+	if !strings.Contains(output, "https://example.com/activate") {
+		t.Errorf("expected output to contain the verification URL, got %q", output)
+	}
+}
+
+func TestReadTokenFromStdin(t *testing.T) {
+	token, err := readTokenFromStdin(strings.NewReader("  secret-token\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if token != "secret-token" {
+		t.Errorf("expected %q, got %q", "secret-token", token)
+	}
+
+	if _, err := readTokenFromStdin(strings.NewReader("\n")); err == nil {
+		t.Error("expected an error for empty input")
+	}
+
+	if _, err := readTokenFromStdin(strings.NewReader("one\ntwo\n")); err == nil {
+		t.Error("expected an error for multiple tokens")
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/whoami", func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer valid-token" {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(`{"data":{"id":"sa-1","type":"service-account","name":"bootstrap"}}`))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %s", err)
+	}
+
+	client := api.NewClient(serverURL.Host, "valid-token")
+	client.HTTPClient = server.Client()
+
+	if err := validateToken(context.Background(), client); err != nil {
+		t.Errorf("expected valid token to be accepted, got %s", err)
+	}
+
+	client.Token = "invalid-token"
+	if err := validateToken(context.Background(), client); err == nil {
+		t.Error("expected invalid token to be refused")
+	}
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/discovery"
+	"github.com/registry-tools/rt-cli/internal/mirror"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/publish"
+	"github.com/registry-tools/rt-cli/internal/registry"
+	sdk "github.com/registry-tools/rt-sdk"
+)
+
+func MirrorCommandFactory() (cli.Command, error) {
+	return &mirrorCommand{}, nil
+}
+
+type mirrorCommand struct{}
+
+func (c *mirrorCommand) Help() string {
+	return `
+Usage: rt mirror <source> --to-namespace=<namespace> [options]
+
+  Copy the versions of a module from another registry, Ex:
+  "registry.terraform.io/hashicorp/consul/aws", into a namespace of your
+  registry. Each version is downloaded, re-packed and published with its
+  original version number. Versions that already exist are skipped.
+
+  Archives over http(s) and git sources with https, ssh or file URLs are
+  supported. Credentials for the source registry are read from
+  TF_TOKEN_<hostname> variables, like Terraform.
+
+Options:
+
+  --to-namespace=<namespace>  (Required) The namespace to publish to.
+
+  --versions=<constraint>     Only mirror versions matching the constraint,
+                              Ex: ">= 5.0". By default, every version is
+                              mirrored.
+
+  --dry-run                   Report the versions that would be mirrored
+                              without publishing them.
+`
+}
+
+// terraformTokenForHost returns the token for host from the TF_TOKEN_<host>
+// environment variable that Terraform uses, Ex: TF_TOKEN_app_terraform_io.
+func terraformTokenForHost(host svchost.Hostname) string {
+	name := strings.ReplaceAll(host.String(), "-", "__")
+	name = strings.ReplaceAll(name, ".", "_")
+	return os.Getenv("TF_TOKEN_" + name)
+}
+
+func (c *mirrorCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var namespace, constraint string
+	var dryRun bool
+	f.StringVar(&namespace, "to-namespace", "", "")
+	f.StringVar(&constraint, "versions", "", "")
+	f.BoolVar(&dryRun, "dry-run", false, "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if len(positional) != 1 {
+		log.Printf("[ERROR] Expected exactly one module source address")
+		return 1
+	}
+	if namespace == "" {
+		log.Printf("[ERROR] Required argument %q is missing", "to-namespace")
+		return 1
+	}
+
+	sourceHost, mod, err := module.ParseSource(positional[0])
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	modulesURL, err := discovery.Default.ModulesURL(sourceHost.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to discover the module registry of %s: %s", sourceHost.ForDisplay(), err)
+		return 1
+	}
+
+	sourceToken := terraformTokenForHost(sourceHost)
+	source := &registry.Client{BaseURL: modulesURL, Token: sourceToken}
+
+	sourceVersions, err := source.ModuleVersions(ctx, mod.Namespace, mod.Name, mod.System)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list versions of %s: %s", positional[0], err)
+		return 1
+	}
+
+	client, err := GetAPIClient(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API client: %s", err)
+		return 127
+	}
+
+	existing, err := client.ListModuleVersions(ctx, namespace, mod.Name, mod.System)
+	if err != nil && !api.IsNotFound(err) {
+		log.Printf("[ERROR] Failed to list existing versions: %s", err)
+		return 1
+	}
+
+	var existingVersions []string
+	for _, v := range existing {
+		existingVersions = append(existingVersions, v.Version)
+	}
+
+	missing, err := mirror.Missing(sourceVersions, existingVersions, constraint)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	info := color.New(color.FgCyan, color.Faint)
+	if len(missing) == 0 {
+		info.Printf("All matching versions of %s are already mirrored to %s\n", positional[0], namespace)
+		return 0
+	}
+
+	if dryRun {
+		for _, v := range missing {
+			fmt.Printf("%s\n", v.Original())
+		}
+		info.Printf("%d version(s) would be mirrored to %s\n", len(missing), namespace)
+		return 0
+	}
+
+	sdkclient, err := GetSDK()
+	if err != nil {
+		log.Printf("[ERROR] Failed to create SDK client: %s", err)
+		return 127
+	}
+
+	fetcher := mirror.Fetcher{Tokens: map[string]string{}}
+	if sourceToken != "" {
+		fetcher.Tokens[sourceHost.String()] = sourceToken
+	}
+
+	for _, v := range missing {
+		ma := ModuleArgs{
+			Namespace: namespace,
+			Name:      mod.Name,
+			System:    mod.System,
+			Version:   v.Original(),
+		}
+
+		if err := mirrorVersion(ctx, source, fetcher, mod, sdkclient, ma); err != nil {
+			log.Printf("[ERROR] Failed to mirror version %s: %s", v.Original(), err)
+			return 1
+		}
+
+		info.Printf("Mirrored %s\n", v.Original())
+	}
+
+	color.New(color.FgGreen).Printf("Mirrored %d version(s) of %s to %s\n", len(missing), positional[0], namespace)
+	return 0
+}
+
+// mirrorVersion downloads the version of mod described by ma from the source
+// registry, re-packs it and publishes it.
+func mirrorVersion(ctx context.Context, source *registry.Client, fetcher mirror.Fetcher, mod module.Module, sdkclient sdk.SDK, ma ModuleArgs) error {
+	location, err := source.DownloadSource(ctx, mod.Namespace, mod.Name, mod.System, ma.Version)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "rt-mirror")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	dir, err := fetcher.Fetch(ctx, location, tmp)
+	if err != nil {
+		return err
+	}
+
+	path, _, err := publish.PackAsFile(dir, publish.PackOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pack: %w", err)
+	}
+	defer os.Remove(path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	_, err = publishModuleArchive(ctx, file, info.Size(), sdkclient, ma.Hostname(), ma)
+	return err
+}
+
+func (c *mirrorCommand) Synopsis() string {
+	return "Copy a module from another registry"
+}
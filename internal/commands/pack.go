@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+
+	"github.com/registry-tools/rt-cli/internal/publish"
+	"github.com/registry-tools/rt-cli/internal/summarize"
+)
+
+func PackCommandFactory() (cli.Command, error) {
+	return &packCommand{}, nil
+}
+
+type packCommand struct{}
+
+func (c *packCommand) Help() string {
+	return `
+Usage: rt pack [options]
+       rt pack --explain [options] [path...]
+
+  Pack a module directory into a gzipped tarball, the same way "rt publish"
+  does, without publishing it. The archive can be published later with
+  "rt publish --archive".
+
+  With --explain, nothing is written. Instead, each path in the module
+  directory, or each of the given paths relative to it, is listed with
+  whether it would be packed, and which .terraformignore, .gitignore,
+  rt.yaml or command line rule decided it. Paths packed as a copy of a file
+  outside of the module directory, through a symlink, show the file.
+
+Options:
+
+  --output=<file>          (Required) The path of the archive to write, Ex:
+                           "module.tar.gz". An existing file is replaced.
+
+  --explain                Explain why paths are or aren't packed, instead of
+                           writing an archive.
+
+  --directory=<dir>        The directory containing the module source code.
+                           Defaults to the current directory.
+` + packFlagsHelp + `
+Patterns and options can also be set in the pack section of the module's
+rt.yaml; see "rt publish --help".
+`
+}
+
+func (c *packCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var output, directory string
+	f.StringVar(&output, "output", "", "")
+	f.StringVar(&directory, "directory", ".", "")
+
+	var pf packFlags
+	pf.register(f)
+
+	var explain bool
+	f.BoolVar(&explain, "explain", false, "")
+
+	paths, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if explain && output != "" {
+		log.Printf("[ERROR] --explain and --output cannot be used together")
+		return 1
+	}
+	if !explain && len(paths) > 0 {
+		log.Printf("[ERROR] Paths can only be given with --explain")
+		return 1
+	}
+	if !explain && output == "" {
+		log.Printf("[ERROR] Required argument \"output\" is missing")
+		return 1
+	}
+
+	options, err := pf.options(directory)
+	if err != nil {
+		log.Printf("[ERROR] Failed to read pack options: %s", err)
+		return 1
+	}
+
+	if explain {
+		return explainPack(directory, options, paths)
+	}
+
+	size, err := publish.PackToFile(directory, output, options)
+	if err != nil {
+		log.Printf("[ERROR] Failed to pack directory %q: %s", directory, err)
+		return 2
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		log.Printf("[ERROR] Failed to stat archive file: %s", err)
+		return 2
+	}
+
+	fmt.Printf("Packed %s into %s: %s (%s compressed)\n", directory, output, summarize.HumanizeBytes(size), summarize.HumanizeBytes(info.Size()))
+	return 0
+}
+
+func (c *packCommand) Synopsis() string {
+	return "Pack a module directory into an archive"
+}
+
+// explainPack prints whether each of paths in directory, or every path if
+// there are none, is packed, and why.
+func explainPack(directory string, options publish.PackOptions, paths []string) int {
+	explanations, err := publish.Explain(directory, options)
+	if err != nil {
+		log.Printf("[ERROR] Failed to pack directory %q: %s", directory, err)
+		return 2
+	}
+
+	if len(paths) == 0 {
+		printExplanations(explanations)
+		return 0
+	}
+
+	status := 0
+	for _, p := range paths {
+		selected := explanations.Select(p)
+		if selected == nil {
+			log.Printf("[ERROR] %q does not exist in %s", p, directory)
+			status = 1
+			continue
+		}
+		printExplanations(selected)
+	}
+	return status
+}
+
+func printExplanations(explanations publish.Explanations) {
+	included := color.New(color.FgGreen)
+	excluded := color.New(color.FgRed)
+	detail := color.New(color.FgCyan, color.Faint)
+
+	width := 0
+	for _, explanation := range explanations {
+		width = max(width, len(explanation.Path))
+	}
+
+	for _, explanation := range explanations {
+		if explanation.Included {
+			included.Printf("%-9s", "included")
+		} else {
+			excluded.Printf("%-9s", "excluded")
+		}
+		fmt.Printf("%-*s  ", width, explanation.Path)
+		detail.Println(explanation.Reason)
+		if explanation.Target != "" {
+			detail.Printf("%-9s%-*s  -> %s\n", "", width, "", explanation.Target)
+		}
+	}
+}
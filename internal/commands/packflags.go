@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"flag"
+
+	"github.com/registry-tools/rt-cli/internal/moduleconfig"
+	"github.com/registry-tools/rt-cli/internal/publish"
+)
+
+// packFlags are the options of commands that pack a module directory, which
+// control the files in the archive together with the module's rt.yaml.
+type packFlags struct {
+	include          stringSliceFlag
+	exclude          stringSliceFlag
+	gitIgnore        bool
+	preserveSymlinks bool
+}
+
+const packFlagsHelp = `
+  --include=<pattern>      Only pack paths matching the pattern, in .terraformignore
+                           syntax, Ex: "*.tf". May be repeated.
+
+  --exclude=<pattern>      Don't pack paths matching the pattern, in addition to
+                           .terraformignore, Ex: "test/". May be repeated.
+
+  --gitignore              Don't pack paths ignored by .gitignore files.
+
+  --preserve-symlinks      Refuse to pack symlinks that point outside of the module
+                           directory, instead of packing a copy of their target.
+`
+
+func (p *packFlags) register(f *flag.FlagSet) {
+	f.Var(&p.include, "include", "")
+	f.Var(&p.exclude, "exclude", "")
+	f.BoolVar(&p.gitIgnore, "gitignore", false, "")
+	f.BoolVar(&p.preserveSymlinks, "preserve-symlinks", false, "")
+}
+
+// set reports whether any of the flags were given.
+func (p *packFlags) set() bool {
+	return len(p.include) > 0 || len(p.exclude) > 0 || p.gitIgnore || p.preserveSymlinks
+}
+
+// options combines the pack section of the rt.yaml in dir with the flags.
+// Patterns from rt.yaml are applied before those from flags.
+func (p *packFlags) options(dir string) (publish.PackOptions, error) {
+	config, err := moduleconfig.Load(dir)
+	if err != nil {
+		return publish.PackOptions{}, err
+	}
+
+	options := publish.PackOptions{
+		GitIgnore:        p.gitIgnore || config.Pack.GitIgnore,
+		PreserveSymlinks: p.preserveSymlinks || config.Pack.PreserveSymlinks,
+	}
+
+	sources := []struct {
+		rules    *publish.IgnoreRules
+		source   string
+		patterns []string
+	}{
+		{&options.Include, moduleconfig.FileName + " pack.include", config.Pack.Include},
+		{&options.Include, "--include", p.include},
+		{&options.Exclude, moduleconfig.FileName + " pack.exclude", config.Pack.Exclude},
+		{&options.Exclude, "--exclude", p.exclude},
+	}
+	for _, s := range sources {
+		for _, pattern := range s.patterns {
+			rule, err := publish.NewIgnoreRule(pattern, s.source, 0)
+			if err != nil {
+				return publish.PackOptions{}, err
+			}
+			*s.rules = append(*s.rules, rule)
+		}
+	}
+	return options, nil
+}
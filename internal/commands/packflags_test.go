@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackFlagsOptions(t *testing.T) {
+	dir := t.TempDir()
+	config := "pack:\n  exclude: [\"*.md\"]\n  preserve_symlinks: true\n"
+	if err := os.WriteFile(filepath.Join(dir, "rt.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var pf packFlags
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	pf.register(f)
+	if err := f.Parse([]string{"--exclude", "!README.md", "--include=*.tf,*.md", "--gitignore"}); err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := pf.options(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !options.GitIgnore || !options.PreserveSymlinks {
+		t.Errorf("expected gitignore and preserve_symlinks, got %+v", options)
+	}
+	if len(options.Include) != 2 || options.Include[0].Source != "--include" {
+		t.Errorf("unexpected include rules %v", options.Include)
+	}
+
+	// Flags are applied after rt.yaml, so they can re-include paths
+	if len(options.Exclude) != 2 || options.Exclude[0].Source != "rt.yaml pack.exclude" || !options.Exclude[1].Negated {
+		t.Errorf("unexpected exclude rules %v", options.Exclude)
+	}
+	if options.Exclude.Excludes("README.md", false) || !options.Exclude.Excludes("CHANGELOG.md", false) {
+		t.Error("expected only CHANGELOG.md to be excluded")
+	}
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/ci"
+	"github.com/registry-tools/rt-cli/internal/discovery"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/preview"
+)
+
+// errPreviewRequiresGitHub is returned when preview publishing is requested
+// outside of a GitHub Actions pull request workflow.
+var errPreviewRequiresGitHub = errors.New("previews can only be published from GitHub Actions")
+
+// pullRequestFromGitHubActions returns the number of the pull request that
+// triggered the workflow.
+func pullRequestFromGitHubActions(p ci.Provider) (*ci.GitHubActions, int, error) {
+	gha, ok := p.(*ci.GitHubActions)
+	if !ok {
+		return nil, 0, errPreviewRequiresGitHub
+	}
+
+	number, err := preview.PullRequestNumber(gha.Action.Getenv("GITHUB_REF"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("the workflow must be triggered by a pull request: %w", err)
+	}
+
+	return gha, number, nil
+}
+
+// applyPreviewVersion replaces the version of ma with the preview version for
+// the pull request being built, and returns the pull request number.
+func applyPreviewVersion(p ci.Provider, ma *ModuleArgs) (int, error) {
+	gha, number, err := pullRequestFromGitHubActions(p)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := preview.Version(ma.Version, number, gha.Action.Getenv("GITHUB_SHA"))
+	if err != nil {
+		return 0, err
+	}
+
+	ma.Version = v
+	return number, nil
+}
+
+// previewCommentMarker identifies the pull request comment for a module, so
+// that later pushes update it rather than adding another.
+func previewCommentMarker(m module.Module, host svchost.Hostname) string {
+	return fmt.Sprintf("<!-- rt-preview: %s -->", m.Source(host))
+}
+
+// commentPreview comments on the pull request with the usage of the preview
+// version.
+func commentPreview(ctx context.Context, p ci.Provider, number int, m module.Module, host svchost.Hostname) error {
+	gha, ok := p.(*ci.GitHubActions)
+	if !ok {
+		return errPreviewRequiresGitHub
+	}
+
+	body := fmt.Sprintf("### Module preview published\n\nVersion `%s` of `%s` was published from this pull request:\n\n```hcl\n%s```\n\nPreview versions are yanked when the pull request is closed.\n",
+		m.Version, m.Source(host), m.ToTerraformExample(host))
+
+	return gha.CommentOnPullRequest(ctx, number, previewCommentMarker(m, host), body)
+}
+
+// runCIPreviewCleanup yanks every preview version published for the pull
+// request that triggered the workflow. It returns the exit status of the
+// command.
+func runCIPreviewCleanup(ctx context.Context, p ci.Provider) int {
+	ma, err := moduleArgsFromCI(p, false)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch required input arguments: %s", err)
+		return 1
+	}
+
+	gha, number, err := pullRequestFromGitHubActions(p)
+	if err != nil {
+		log.Printf("[ERROR] Failed to determine the pull request: %s", err)
+		return 1
+	}
+
+	hostname, err := svchost.ForComparison(ma.Hostname())
+	if err != nil {
+		log.Printf("[ERROR] Failed to parse hostname: %s", err)
+		return 127
+	}
+
+	token, err := tokenFromGitHubActions(ctx, gha.Action, hostname.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to authenticate: %s", err)
+		return 127
+	}
+
+	apiURL, err := discovery.Default.APIURL(hostname.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to discover the API: %s", err)
+		return 127
+	}
+
+	yanked, err := yankPreviews(ctx, &api.Client{BaseURL: apiURL, Token: token}, *ma, number)
+	for _, v := range yanked {
+		gha.Action.Infof("Yanked preview version %s", v)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to yank preview versions: %s", err)
+		return 1
+	}
+
+	if err := p.SetOutput("yanked-versions", fmt.Sprint(len(yanked))); err != nil {
+		log.Printf("[ERROR] Failed to set the %q output: %s", "yanked-versions", err)
+	}
+
+	return 0
+}
+
+// yankPreviews yanks the versions of the module that were published as
+// previews of pull request number, and returns the versions that were yanked.
+func yankPreviews(ctx context.Context, client *api.Client, ma ModuleArgs, number int) ([]string, error) {
+	versions, err := client.ListModuleVersions(ctx, ma.Namespace, ma.Name, ma.System)
+	if err != nil {
+		return nil, err
+	}
+
+	var yanked []string
+	for _, v := range versions {
+		if v.Yanked || !preview.IsPreview(v.Version, number) {
+			continue
+		}
+
+		if err := client.YankModuleVersion(ctx, v.ID, fmt.Sprintf("pull request #%d was closed", number)); err != nil {
+			return yanked, fmt.Errorf("failed to yank %s: %w", v.Version, err)
+		}
+		yanked = append(yanked, v.Version)
+	}
+
+	return yanked, nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/sethvargo/go-githubactions"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/ci"
+)
+
+func TestApplyPreviewVersion(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_REF": "refs/pull/42/merge",
+		"GITHUB_SHA": "0123456789abcdef",
+	}
+	p := &ci.GitHubActions{Action: githubactions.New(githubactions.WithGetenv(func(k string) string { return env[k] }), githubactions.WithWriter(io.Discard))}
+
+	ma := &ModuleArgs{Version: "1.4.0"}
+	number, err := applyPreviewVersion(p, ma)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if number != 42 || ma.Version != "1.4.0-pr.42.g0123456" {
+		t.Errorf("unexpected preview %d %q", number, ma.Version)
+	}
+
+	env["GITHUB_REF"] = "refs/heads/main"
+	if _, err := applyPreviewVersion(p, &ModuleArgs{Version: "1.4.0"}); err == nil {
+		t.Error("expected an error outside of a pull request")
+	}
+
+	if _, err := applyPreviewVersion(ci.NewEnv(func(string) string { return "" }), &ModuleArgs{Version: "1.4.0"}); err != errPreviewRequiresGitHub {
+		t.Errorf("expected errPreviewRequiresGitHub, got %v", err)
+	}
+}
+
+func TestYankPreviews(t *testing.T) {
+	var yanked []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/terraform-module-versions", func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte(`{"data":[
+			{"id":"mv-1","version":"1.4.0"},
+			{"id":"mv-2","version":"1.4.0-pr.42.0123456"},
+			{"id":"mv-3","version":"1.4.0-pr.42.89abcde","yanked":true},
+			{"id":"mv-4","version":"1.4.0-pr.420.0123456"},
+			{"id":"mv-5","version":"1.5.0-pr.42.fedcba9"}
+		]}`))
+	})
+	mux.HandleFunc("PATCH /api/terraform-module-versions/{id}", func(res http.ResponseWriter, req *http.Request) {
+		yanked = append(yanked, req.PathValue("id"))
+		res.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	client := &api.Client{BaseURL: baseURL, Token: "test-token"}
+
+	versions, err := yankPreviews(context.Background(), client, ModuleArgs{Namespace: "platform", Name: "vpc", System: "aws"}, 42)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if len(versions) != 2 || versions[0] != "1.4.0-pr.42.0123456" || versions[1] != "1.5.0-pr.42.fedcba9" {
+		t.Errorf("unexpected yanked versions %q", versions)
+	}
+	if len(yanked) != 2 || yanked[0] != "mv-2" || yanked[1] != "mv-5" {
+		t.Errorf("unexpected yank requests %q", yanked)
+	}
+}
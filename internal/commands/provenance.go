@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/ci"
+	"github.com/registry-tools/rt-cli/internal/discovery"
+	"github.com/registry-tools/rt-cli/internal/provenance"
+)
+
+// moduleProvenance describes how the module version in ma was built. The
+// source recorded in ma.Metadata takes precedence over the CI build's.
+// parameters are recorded in addition to the module arguments.
+func moduleProvenance(build ci.Build, ma ModuleArgs, host svchost.Hostname, parameters map[string]string, started time.Time) provenance.Statement {
+	all := map[string]string{
+		"namespace": ma.Namespace,
+		"name":      ma.Name,
+		"system":    ma.System,
+		"version":   ma.Version,
+		"host":      host.String(),
+	}
+	for k, v := range parameters {
+		all[k] = v
+	}
+
+	b := provenance.Build{
+		Host:          host,
+		Module:        ma.Module(),
+		ArchiveSHA256: ma.Metadata.ArchiveSHA256,
+		Parameters:    all,
+		RepositoryURL: build.RepositoryURL,
+		Commit:        build.Commit,
+		Ref:           build.Ref,
+		Workflow:      build.Workflow,
+		BuilderID:     build.BuilderID,
+		InvocationID:  build.InvocationID,
+		StartedOn:     started,
+		FinishedOn:    time.Now(),
+	}
+	if ma.Metadata.SourceCommit != "" {
+		b.RepositoryURL = ma.Metadata.SourceRepository
+		b.Commit = ma.Metadata.SourceCommit
+		b.Ref = parameters["ref"]
+	}
+
+	return provenance.New(b)
+}
+
+// uploadProvenance attaches the provenance statement to the published module
+// version with the specified ID.
+func uploadProvenance(ctx context.Context, client *api.Client, id string, statement provenance.Statement) error {
+	data, err := statement.JSON()
+	if err != nil {
+		return err
+	}
+
+	return client.CreateAttachment(ctx, id, api.Attachment{
+		Name:      provenance.AttachmentName,
+		MediaType: provenance.MediaType,
+		Content:   string(data),
+	})
+}
+
+// apiClientForCI returns an API client using the credentials available to
+// the CI provider.
+func apiClientForCI(ctx context.Context, p ci.Provider, hostname string) (*api.Client, error) {
+	gha, ok := p.(*ci.GitHubActions)
+	if !ok {
+		return getAPIClientForHost(ctx, hostname)
+	}
+
+	apiURL, err := discovery.Default.APIURL(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := tokenFromGitHubActions(ctx, gha.Action, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Client{BaseURL: apiURL, Token: token}, nil
+}
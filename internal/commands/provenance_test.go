@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	svchost "github.com/hashicorp/terraform-svchost"
+	sdk "github.com/registry-tools/rt-sdk"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/ci"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/provenance"
+	"github.com/registry-tools/rt-cli/internal/publish"
+	"github.com/registry-tools/rt-cli/internal/server"
+)
+
+func TestModuleProvenance(t *testing.T) {
+	build := ci.Build{
+		BuilderID:     "https://github.com/org/modules/.github/workflows/release.yml@refs/heads/main",
+		Workflow:      "org/modules/.github/workflows/release.yml@refs/heads/main",
+		RepositoryURL: "https://github.com/org/modules",
+		Commit:        "1111111",
+		Ref:           "refs/heads/main",
+	}
+	ma := ModuleArgs{Namespace: "platform", Name: "vpc", System: "aws", Version: "1.2.3"}
+	ma.Metadata.ArchiveSHA256 = "abc123"
+	host := svchost.Hostname("registrytools.cloud")
+
+	statement := moduleProvenance(build, ma, host, map[string]string{"directory": "modules/vpc"}, time.Now())
+
+	definition := statement.Predicate.BuildDefinition
+	if definition.ExternalParameters["namespace"] != "platform" || definition.ExternalParameters["directory"] != "modules/vpc" || definition.ExternalParameters["host"] != "registrytools.cloud" {
+		t.Errorf("unexpected parameters %v", definition.ExternalParameters)
+	}
+	if definition.ResolvedDependencies[0].Digest["gitCommit"] != "1111111" {
+		t.Errorf("expected the commit of the build, got %+v", definition.ResolvedDependencies)
+	}
+	if statement.Subject[0].Digest["sha256"] != "abc123" {
+		t.Errorf("unexpected subject %+v", statement.Subject)
+	}
+
+	// The commit exported by --from-git takes precedence over the build's
+	ma.Metadata.SourceRepository = "https://example.com/vpc.git"
+	ma.Metadata.SourceCommit = "2222222"
+	statement = moduleProvenance(build, ma, host, map[string]string{"ref": "v1.2.3"}, time.Now())
+	dependency := statement.Predicate.BuildDefinition.ResolvedDependencies[0]
+	if dependency.URI != "git+https://example.com/vpc.git@v1.2.3" || dependency.Digest["gitCommit"] != "2222222" {
+		t.Errorf("expected the exported commit, got %+v", dependency)
+	}
+}
+
+func TestUploadProvenance(t *testing.T) {
+	srv := httptest.NewTLSServer(server.New(t.TempDir(), ""))
+	t.Cleanup(srv.Close)
+
+	serverURL, _ := url.Parse(srv.URL)
+	client, _ := sdk.NewInsecureSDKForTesting(serverURL.Host)
+
+	path, _, err := publish.PackAsFile("../publish/fixtures/moduleA", publish.PackOptions{})
+	if err != nil {
+		t.Fatalf("Failed to pack directory: %s", err)
+	}
+	defer os.Remove(path)
+	file, _ := os.Open(path)
+	defer file.Close()
+
+	mod := module.Module{Namespace: "platform", Name: "vpc", System: "aws", Version: "1.0.0"}
+	published, err := publish.Publisher{SDK: client}.Publish(context.Background(), mod, file)
+	if err != nil {
+		t.Fatalf("Failed to publish module: %s", err)
+	}
+
+	ma := ModuleArgs{Namespace: mod.Namespace, Name: mod.Name, System: mod.System, Version: mod.Version}
+	statement := moduleProvenance(ci.Build{}, ma, svchost.Hostname(serverURL.Host), nil, time.Now())
+
+	apiClient := &api.Client{BaseURL: serverURL, HTTPClient: srv.Client()}
+	ctx := context.Background()
+	if err := uploadProvenance(ctx, apiClient, published.ID, statement); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	found, err := findModuleVersion(ctx, apiClient, mod)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	attachment, err := apiClient.GetAttachment(ctx, found.ID, provenance.AttachmentName)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	var actual provenance.Statement
+	if err := json.Unmarshal([]byte(attachment.Content), &actual); err != nil {
+		t.Fatalf("expected the attachment to be a statement, got %s", err)
+	}
+	if actual.Predicate.RunDetails.Builder.ID != provenance.DefaultBuilderID || attachment.MediaType != provenance.MediaType {
+		t.Errorf("unexpected provenance %+v", attachment)
+	}
+
+	mod.Version = "2.0.0"
+	if _, err := findModuleVersion(ctx, apiClient, mod); err != errVersionNotFound {
+		t.Errorf("expected a missing version to be not found, got %v", err)
+	}
+}
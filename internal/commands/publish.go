@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"crypto/ed25519"
 	"flag"
 	"fmt"
 	"io"
@@ -9,14 +10,19 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/hashicorp/cli"
 	svchost "github.com/hashicorp/terraform-svchost"
 	sdk "github.com/registry-tools/rt-sdk"
 
+	"github.com/registry-tools/rt-cli/internal/ci"
+	"github.com/registry-tools/rt-cli/internal/docs"
+	"github.com/registry-tools/rt-cli/internal/gitsource"
 	"github.com/registry-tools/rt-cli/internal/module"
 	"github.com/registry-tools/rt-cli/internal/publish"
+	"github.com/registry-tools/rt-cli/internal/signing"
 	"github.com/registry-tools/rt-cli/internal/summarize"
 )
 
@@ -36,6 +42,19 @@ type ModuleArgs struct {
 	Name      string
 	System    string
 	Directory string
+	// Host is the hostname of the registry. When empty, the hostname is read
+	// from REGISTRY_TOOLS_HOSTNAME.
+	Host string
+	// Metadata is recorded with the published module version.
+	Metadata publish.Metadata
+}
+
+// Hostname returns the registry hostname that the module is published to.
+func (m ModuleArgs) Hostname() string {
+	if m.Host != "" {
+		return m.Host
+	}
+	return registryHostname()
 }
 
 func (m ModuleArgs) Module() module.Module {
@@ -69,16 +88,84 @@ Options:
 
   --directory=<dir>        The directory containing the module source code. Defaults
                            to the current directory.
+
+  --from-git=<repository>  Publish from a git repository instead of a directory. The
+                           repository may be a URL or a local path, and the commit
+                           is recorded with the published version. The name and
+                           system default to being derived from the repository name.
+
+  --ref=<ref>              The branch, tag or commit to publish with --from-git.
+                           Defaults to HEAD. Ex: "v1.2.3".
+
+  --subdir=<path>          The directory of the module within the repository when
+                           using --from-git. Ex: "modules/vpc".
+
+  --archive=<file>         Publish a gzipped tarball built by "rt pack" or another
+                           tool as-is, instead of packing a directory. The archive
+                           must contain at least one .tf file, and every path in it
+                           must stay within the module directory.
+
+  --sign-key=<path>        Sign the module version and the SHA-256 of the archive
+                           with a PEM encoded ed25519 private key, Ex: one created
+                           by "openssl genpkey -algorithm ed25519". The signature is
+                           attached to the version, and can be checked with
+                           "rt verify".
+
+  --sbom                   Attach a CycloneDX SBOM of the providers and modules
+                           that the module depends on to the published version.
+                           Print it with "rt sbom".
+
+  --provenance             Attach a SLSA provenance statement to the published
+                           version, recording the source commit, the CI workflow
+                           if there is one, the arguments and the archive digest.
+                           View it with "rt show --provenance".
+
+  --check-docs             Refuse to publish if the documentation in the module's
+                           README.md is out of date. See "rt docs".
+
+  --test                   Before publishing, run "terraform fmt -check" against each
+                           directory in the module's examples directory, then
+                           "terraform init -backend=false" and "terraform validate"
+                           against a copy in which calls of this module are
+                           rewritten to the local directory. The module is not
+                           published if any example fails. Skipped if terraform is
+                           not installed.
+
+  --terraform-path=<path>  The terraform binary used by --test. Defaults to the one
+                           in PATH.
+` + packFlagsHelp + `
+The module's rt.yaml can set the same options for every publish, in .terraformignore
+syntax, and patterns given as flags are applied after them:
+
+  pack:
+    include: ["*.tf", "modules/", "examples/", "README.md"]
+    exclude: ["test/"]
+    gitignore: true
+    preserve_symlinks: true
+
+If the module has an rt.yaml file, it is checked with "rt lint" first, and is not
+published if any rule with the "error" severity fails.
 `
 }
 
-func publishModuleArchive(ctx context.Context, reader io.ReadSeeker, size int64, sdkclient sdk.SDK, margs ModuleArgs) (*summarize.Summary, error) {
+// publishModuleArchive publishes the gzipped archive read from reader, whose
+// size in bytes is recorded in the summary.
+func publishModuleArchive(ctx context.Context, reader io.ReadSeeker, size int64, sdkclient sdk.SDK, hostname string, margs ModuleArgs) (*summarize.Summary, error) {
+	if margs.Metadata.ArchiveSHA256 == "" {
+		digest, err := publish.ReaderSHA256(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash archive: %w", err)
+		}
+		margs.Metadata.ArchiveSHA256 = digest
+	}
+
 	// Publish the module and summarize the result
 	publisher := publish.Publisher{
-		SDK: sdkclient,
+		SDK:      sdkclient,
+		Metadata: margs.Metadata,
 	}
 
-	host, err := svchost.ForComparison(sdkclient.Endpoint().Host)
+	host, err := svchost.ForComparison(hostname)
 	if err != nil {
 		return nil, fmt.Errorf("invalid host: %w", err)
 	}
@@ -91,16 +178,14 @@ func publishModuleArchive(ctx context.Context, reader io.ReadSeeker, size int64,
 	}
 
 	result := summarize.NewSummary(size, host, ver)
+	result.SourceCommit = margs.Metadata.SourceCommit
+	result.SHA256 = margs.Metadata.ArchiveSHA256
 	return &result, nil
 }
 
-func moduleArgsFromCWD() (*ModuleArgs, error) {
-	pwd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current working directory: %w", err)
-	}
-
-	base := path.Base(pwd)
+// nameAndSystemFromBase derives the module name and system from the name of
+// a directory or repository structured like "terraform-<system>-<name>".
+func nameAndSystemFromBase(base string) (string, string) {
 	name := base
 	system := "null"
 
@@ -114,6 +199,27 @@ func moduleArgsFromCWD() (*ModuleArgs, error) {
 		}
 	}
 
+	return name, system
+}
+
+// repositoryBase returns the name of a git repository from its URL or path,
+// Ex: "terraform-aws-vpc" for "git@github.com:org/terraform-aws-vpc.git".
+func repositoryBase(repository string) string {
+	repository = strings.TrimSuffix(strings.TrimRight(repository, "/"), ".git")
+	if i := strings.LastIndexAny(repository, "/:"); i >= 0 {
+		repository = repository[i+1:]
+	}
+	return repository
+}
+
+func moduleArgsFromCWD() (*ModuleArgs, error) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	name, system := nameAndSystemFromBase(path.Base(pwd))
+
 	return &ModuleArgs{
 		Directory: pwd,
 		Name:      name,
@@ -146,28 +252,161 @@ func (c *publishCommand) Run(args []string) int {
 	f.StringVar(&ma.System, "system", defaults.System, "")
 	f.StringVar(&ma.Directory, "directory", defaults.Directory, "")
 
+	var fromGit, ref, subdir string
+	f.StringVar(&fromGit, "from-git", "", "")
+	f.StringVar(&ref, "ref", "", "")
+	f.StringVar(&subdir, "subdir", ".", "")
+
+	var archive, signKeyPath string
+	var withSBOM, withProvenance, checkDocs bool
+	f.StringVar(&archive, "archive", "", "")
+	f.StringVar(&signKeyPath, "sign-key", "", "")
+	f.BoolVar(&withSBOM, "sbom", false, "")
+	f.BoolVar(&withProvenance, "provenance", false, "")
+	f.BoolVar(&checkDocs, "check-docs", false, "")
+
+	var pf packFlags
+	pf.register(f)
+
+	var runTests bool
+	var terraformPath string
+	f.BoolVar(&runTests, "test", false, "")
+	f.StringVar(&terraformPath, "terraform-path", "", "")
+
 	if err := f.Parse(args); err != nil {
 		return 1
 	}
 
+	set := map[string]bool{}
+	f.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	ctx := context.Background()
+	started := time.Now()
+
+	if archive != "" && (fromGit != "" || set["directory"] || checkDocs || runTests || pf.set()) {
+		log.Printf("[ERROR] --archive cannot be used with --directory, --from-git, --check-docs, --test or options that control packing")
+		return 1
+	}
+
+	if terraformPath != "" && !runTests {
+		log.Printf("[ERROR] --terraform-path can only be used with --test")
+		return 1
+	}
+
+	if fromGit != "" {
+		if set["directory"] {
+			log.Printf("[ERROR] --directory cannot be used with --from-git")
+			return 1
+		}
+
+		name, system := nameAndSystemFromBase(repositoryBase(fromGit))
+		if !set["name"] {
+			ma.Name = name
+		}
+		if !set["system"] {
+			ma.System = system
+		}
+
+		export, err := gitsource.ExportRef(ctx, fromGit, ref, subdir)
+		if err != nil {
+			log.Printf("[ERROR] Failed to export %q from git: %s", ref, err)
+			return 2
+		}
+		defer export.Close()
+
+		ma.Directory = export.Dir
+		ma.Metadata.SourceCommit = export.Commit
+		// Local paths are not meaningful to anyone else
+		if _, err := os.Stat(fromGit); err != nil {
+			ma.Metadata.SourceRepository = fromGit
+		}
+	} else if set["ref"] || set["subdir"] {
+		log.Printf("[ERROR] --ref and --subdir can only be used with --from-git")
+		return 1
+	}
+
 	c.requireArgumentOrExit("namespace", ma.Namespace)
 	c.requireArgumentOrExit("version", ma.Version)
 	c.requireArgumentOrExit("name", ma.Name)
 	c.requireArgumentOrExit("system", ma.System)
 
+	if checkDocs {
+		current, updated, err := docs.Readme(ma.Directory)
+		if err != nil {
+			log.Printf("[ERROR] Failed to check the documentation: %s", err)
+			return 1
+		}
+		if current != updated {
+			log.Printf("[ERROR] The documentation in %s is out of date. Run \"rt docs --inject\" to update it.", docs.ReadmeName)
+			return 1
+		}
+	}
+
+	if archive == "" {
+		findings, err := lintConfiguredModule(ma.Directory)
+		if err != nil {
+			log.Printf("[ERROR] Failed to lint module in %q: %s", ma.Directory, err)
+			return 1
+		}
+		if findings.HasErrors() {
+			printFindings(os.Stderr, ma.Directory, findings)
+			log.Printf("[ERROR] Module in directory %q has lint errors. Run \"rt lint\" for details.", ma.Directory)
+			return 1
+		}
+	}
+
+	hostname := registryHostname()
+	host, err := svchost.ForComparison(hostname)
+	if err != nil {
+		log.Printf("[ERROR] Invalid host %q: %s", hostname, err)
+		return 1
+	}
+
+	if runTests && !testModuleExamples(ctx, host, ma, terraformPath) {
+		return 1
+	}
+
+	var signKey ed25519.PrivateKey
+	if signKeyPath != "" {
+		signKey, err = signing.LoadPrivateKey(signKeyPath)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load signing key: %s", err)
+			return 1
+		}
+	}
+
 	sdkclient, err := GetSDK()
 	if err != nil {
 		log.Printf("[ERROR] Failed to create SDK client: %s", err)
 		return 127
 	}
 
-	// Pack the source directory into a temporary file
-	path, size, err := publish.PackAsFile(ma.Directory)
-	if err != nil {
-		log.Printf("[ERROR] Failed to pack directory %q: %s", ma.Directory, err)
-		return 2
+	var path string
+	var size int64
+	if archive != "" {
+		// Publish the archive as-is, after checking that it is safe to extract
+		archiveInfo, err := publish.ValidateArchive(archive)
+		if err != nil {
+			log.Printf("[ERROR] Invalid archive %q: %s", archive, err)
+			return 2
+		}
+		path = archive
+		size = archiveInfo.Size
+	} else {
+		options, err := pf.options(ma.Directory)
+		if err != nil {
+			log.Printf("[ERROR] Failed to read pack options: %s", err)
+			return 1
+		}
+
+		// Pack the source directory into a temporary file
+		path, size, err = publish.PackAsFile(ma.Directory, options)
+		if err != nil {
+			log.Printf("[ERROR] Failed to pack directory %q: %s", ma.Directory, err)
+			return 2
+		}
+		defer os.Remove(path)
 	}
-	defer os.Remove(path)
 
 	info, err := os.Stat(path)
 	if err != nil {
@@ -175,7 +414,42 @@ func (c *publishCommand) Run(args []string) int {
 		return 2
 	}
 
-	if !c.confirm(size, info.Size(), ma, svchost.ForDisplay(sdkclient.Endpoint().Host)) {
+	ma.Metadata.ArchiveSHA256, err = publish.FileSHA256(path)
+	if err != nil {
+		log.Printf("[ERROR] Failed to hash archive file: %s", err)
+		return 2
+	}
+
+	var signature *signing.Signature
+	if signKey != nil {
+		signature, err = signing.Sign(signKey, signingPayload(ma.Module(), ma.Metadata.ArchiveSHA256))
+		if err != nil {
+			log.Printf("[ERROR] Failed to sign archive: %s", err)
+			return 2
+		}
+	}
+
+	var bom []byte
+	if withSBOM {
+		bom, err = moduleSBOM(path, host, ma)
+		if err != nil {
+			log.Printf("[ERROR] Failed to list the dependencies of the module: %s", err)
+			return 2
+		}
+	}
+
+	sourceLabel, source := "", ""
+	if archive != "" {
+		sourceLabel, source = "Archive:", archive
+	}
+	if fromGit != "" {
+		sourceLabel = "Git:"
+		source = fmt.Sprintf("%s (%s)", fromGit, ma.Metadata.SourceCommit[:12])
+		if subdir != "." {
+			source = fmt.Sprintf("%s//%s (%s)", fromGit, subdir, ma.Metadata.SourceCommit[:12])
+		}
+	}
+	if !c.confirm(size, info.Size(), ma, signature != nil, sourceLabel, source, svchost.ForDisplay(hostname)) {
 		log.Printf("[ERROR] User did not confirm")
 		return 1
 	}
@@ -187,8 +461,7 @@ func (c *publishCommand) Run(args []string) int {
 	}
 	defer file.Close()
 
-	ctx := context.Background()
-	summary, err := publishModuleArchive(ctx, file, size, sdkclient, ma)
+	summary, err := publishModuleArchive(ctx, file, info.Size(), sdkclient, hostname, ma)
 	if err != nil {
 		log.Printf("[ERROR] Failed to publish module: %s", err)
 		return 1
@@ -196,10 +469,51 @@ func (c *publishCommand) Run(args []string) int {
 
 	fmt.Print(summary.CLI())
 
+	if signature == nil && bom == nil && !withProvenance {
+		return 0
+	}
+
+	client, err := GetAPIClient(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Module was published successfully, but this program failed to create an API client: %s", err)
+		return 1
+	}
+
+	if signature != nil {
+		if err := uploadSignature(ctx, client, summary.Module.ID, signature); err != nil {
+			log.Printf("[ERROR] Module was published successfully, but this program failed to attach its signature: %s", err)
+			return 1
+		}
+	}
+
+	if bom != nil {
+		if err := uploadSBOM(ctx, client, summary.Module.ID, bom); err != nil {
+			log.Printf("[ERROR] Module was published successfully, but this program failed to attach its SBOM: %s", err)
+			return 1
+		}
+	}
+
+	if withProvenance {
+		parameters := map[string]string{"archive": archive, "from-git": fromGit, "ref": ref}
+		if fromGit != "" {
+			parameters["subdir"] = subdir
+		} else if archive == "" {
+			parameters["directory"] = ma.Directory
+		}
+		statement := moduleProvenance(ci.DetectFromEnvironment().Build(), ma, summary.Host, parameters, started)
+
+		if err := uploadProvenance(ctx, client, summary.Module.ID, statement); err != nil {
+			log.Printf("[ERROR] Module was published successfully, but this program failed to attach its provenance: %s", err)
+			return 1
+		}
+	}
+
 	return 0
 }
 
-func (c *publishCommand) confirm(size int64, sizeCompressed int64, ma ModuleArgs, hostnameForDisplay string) bool {
+// confirm asks the user to confirm publishing. sourceLabel and source
+// describe where the module came from, if it was not a local directory.
+func (c *publishCommand) confirm(size int64, sizeCompressed int64, ma ModuleArgs, signed bool, sourceLabel, source string, hostnameForDisplay string) bool {
 	baseUI := &cli.BasicUi{
 		Reader:      os.Stdin,
 		Writer:      os.Stdout,
@@ -222,15 +536,26 @@ func (c *publishCommand) confirm(size int64, sizeCompressed int64, ma ModuleArgs
 	value.Println(ma.Name)
 	label.Print("System:    ")
 	value.Println(ma.System)
-	label.Print("Directory: ")
-	if ma.Directory != cwd {
-		value.Println(ma.Directory)
+	if source != "" {
+		label.Printf("%-11s", sourceLabel)
+		value.Println(source)
 	} else {
-		value.Println(".")
+		label.Print("Directory: ")
+		if ma.Directory != cwd {
+			value.Println(ma.Directory)
+		} else {
+			value.Println(".")
+		}
 	}
 	label.Print("Size:      ")
 	value.Print(summarize.HumanizeBytes(size))
 	value.Println(fmt.Sprintf(" (%s compressed)", summarize.HumanizeBytes(sizeCompressed)))
+	label.Print("SHA-256:   ")
+	value.Print(ma.Metadata.ArchiveSHA256)
+	if signed {
+		value.Print(" (signed)")
+	}
+	value.Println()
 
 	answer, err := baseUI.Ask(color.YellowString(fmt.Sprintf("Publish to %s? You must type 'yes' to confirm:", host.Sprint(hostnameForDisplay))))
 	if err != nil {
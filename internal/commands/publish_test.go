@@ -0,0 +1,19 @@
+package commands
+
+import "testing"
+
+func TestNameAndSystemFromRepository(t *testing.T) {
+	items := map[string][2]string{
+		"git@github.com:org/terraform-aws-vpc.git":   {"vpc", "aws"},
+		"https://github.com/org/terraform-aws-vpc/":  {"vpc", "aws"},
+		"/srv/git/terraform-google-network-peer.git": {"network-peer", "google"},
+		"../modules": {"modules", "null"},
+	}
+
+	for repository, expected := range items {
+		name, system := nameAndSystemFromBase(repositoryBase(repository))
+		if name != expected[0] || system != expected[1] {
+			t.Errorf("expected %q to be %v, got %q %q", repository, expected, name, system)
+		}
+	}
+}
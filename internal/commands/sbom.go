@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/hashicorp/cli"
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/sbom"
+)
+
+func SBOMCommandFactory() (cli.Command, error) {
+	return &sbomCommand{}, nil
+}
+
+type sbomCommand struct{}
+
+func (c *sbomCommand) Help() string {
+	return `
+Usage: rt sbom <source> --version=<version>
+
+  Print the CycloneDX software bill of materials of a published module
+  version, Ex: "registrytools.cloud/platform/vpc/aws". It lists the providers
+  and modules that the module and its nested modules depend on, with their
+  version constraints, and is attached to a version when it is published with
+  "rt publish --sbom" or by "rt ci publish" with the sbom input.
+
+Options:
+
+  --version=<version>   (Required) The version of the module.
+`
+}
+
+func (c *sbomCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var ver string
+	f.StringVar(&ver, "version", "", "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if len(positional) != 1 {
+		log.Printf("[ERROR] Expected exactly one module source address")
+		return 1
+	}
+	if ver == "" {
+		log.Printf("[ERROR] Required argument %q is missing", "version")
+		return 1
+	}
+
+	host, mod, err := module.ParseSource(positional[0])
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+	mod.Version = ver
+
+	ctx := context.Background()
+
+	client, err := getAPIClientForHost(ctx, host.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API client: %s", err)
+		return 127
+	}
+
+	published, err := findModuleVersion(ctx, client, mod)
+	if err != nil {
+		log.Printf("[ERROR] Failed to find %s version %s: %s", positional[0], ver, err)
+		return 1
+	}
+
+	attachment, err := client.GetAttachment(ctx, published.ID, sbom.AttachmentName)
+	if api.IsNotFound(err) {
+		log.Printf("[ERROR] %s version %s has no SBOM", positional[0], ver)
+		return 1
+	} else if err != nil {
+		log.Printf("[ERROR] Failed to get SBOM: %s", err)
+		return 1
+	}
+
+	fmt.Println(attachment.Content)
+	return 0
+}
+
+func (c *sbomCommand) Synopsis() string {
+	return "Print the dependencies of a published module version"
+}
+
+// moduleSBOM returns the SBOM of the module archive at path, which is
+// published as the version in ma.
+func moduleSBOM(path string, host svchost.Hostname, ma ModuleArgs) ([]byte, error) {
+	deps, err := sbom.ScanArchive(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return sbom.New(host, ma.Module(), deps, time.Now()).JSON()
+}
+
+// uploadSBOM attaches the SBOM to the published module version with the
+// specified ID.
+func uploadSBOM(ctx context.Context, client *api.Client, id string, data []byte) error {
+	return client.CreateAttachment(ctx, id, api.Attachment{
+		Name:      sbom.AttachmentName,
+		MediaType: sbom.MediaType,
+		Content:   string(data),
+	})
+}
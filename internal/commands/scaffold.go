@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/docs"
+	"github.com/registry-tools/rt-cli/internal/gitsource"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/scaffold"
+)
+
+var (
+	// moduleNamePattern and systemPattern keep the directory name parseable
+	// by nameAndSystemFromBase: the system cannot contain a hyphen.
+	moduleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	systemPattern     = regexp.MustCompile(`^[a-z0-9]+$`)
+)
+
+func InitCommandFactory() (cli.Command, error) {
+	return &initCommand{}, nil
+}
+
+type initCommand struct{}
+
+func (c *initCommand) Help() string {
+	return `
+Usage: rt init [options] <name>
+
+  Create a new module in a directory named terraform-<system>-<name>, the
+  name that "rt publish" derives the module's name and system from. It
+  contains main.tf, variables.tf, outputs.tf, versions.tf, a README.md with
+  generated documentation, examples/basic, a .terraformignore, an rt.yaml
+  and a GitHub Actions workflow that publishes tags like "v1.2.3".
+
+Options:
+
+  --system=<system>      The provider system of the module, Ex: "aws".
+                         Defaults to "null".
+
+  --namespace=<name>     The namespace the module is published to, used in the
+                         README and the workflow.
+
+  --directory=<path>     The directory to create the module in. Defaults to
+                         the current directory.
+
+  --template=<source>    Render the templates in a local directory or a git
+                         repository instead of the built-in ones. Every file is
+                         rendered as a Go template with .Name, .System,
+                         .Namespace, .Host and .Source, and a ".tmpl" suffix is
+                         removed from its name.
+
+  --template-ref=<ref>   The branch, tag or commit of a git --template.
+                         Defaults to HEAD.
+`
+}
+
+func (c *initCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var system, namespace, directory, templateSource, templateRef string
+	f.StringVar(&system, "system", "null", "")
+	f.StringVar(&namespace, "namespace", "", "")
+	f.StringVar(&directory, "directory", ".", "")
+	f.StringVar(&templateSource, "template", "", "")
+	f.StringVar(&templateRef, "template-ref", "", "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+	if len(positional) != 1 {
+		log.Printf("[ERROR] Expected the name of the module, Ex: rt init vpc --system=aws")
+		return 1
+	}
+
+	name := positional[0]
+	if !moduleNamePattern.MatchString(name) {
+		log.Printf("[ERROR] Invalid module name %q: use lowercase letters, digits, hyphens and underscores", name)
+		return 1
+	}
+	if !systemPattern.MatchString(system) {
+		log.Printf("[ERROR] Invalid system %q: use lowercase letters and digits", system)
+		return 1
+	}
+	if templateRef != "" && templateSource == "" {
+		log.Printf("[ERROR] --template-ref can only be used with --template")
+		return 1
+	}
+
+	hostname := registryHostname()
+	host, err := svchost.ForComparison(hostname)
+	if err != nil {
+		log.Printf("[ERROR] Invalid host %q: %s", hostname, err)
+		return 1
+	}
+
+	data := scaffold.Data{
+		Name:      name,
+		System:    system,
+		Namespace: namespace,
+		Host:      hostname,
+	}
+	if data.Namespace == "" {
+		data.Namespace = "<namespace>"
+	}
+	data.Source = module.Module{Namespace: data.Namespace, Name: name, System: system}.Source(host)
+
+	templates, cleanup, err := loadTemplates(context.Background(), templateSource, templateRef)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load templates from %q: %s", templateSource, err)
+		return 1
+	}
+	defer cleanup()
+
+	dir := filepath.Join(directory, data.Directory())
+	created, err := scaffold.Render(templates, dir, data)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create module: %s", err)
+		return 1
+	}
+
+	info := color.New(color.FgCyan, color.Faint)
+	for _, file := range created {
+		info.Printf("  %s\n", filepath.Join(dir, file))
+	}
+
+	if err := injectScaffoldDocs(dir); err != nil {
+		log.Printf("[WARN] Failed to generate the documentation in %s: %s", docs.ReadmeName, err)
+	}
+
+	color.New(color.FgGreen).Printf("Created module %s in %s\n", name, dir)
+	if namespace == "" {
+		info.Printf("No --namespace was given; replace <namespace> in the module's files with the namespace to publish to\n")
+	}
+	return 0
+}
+
+func (c *initCommand) Synopsis() string {
+	return "Create a new module from templates"
+}
+
+// loadTemplates returns the built-in templates, or the templates in source,
+// which may be a local directory or a git repository. The returned function
+// removes any files that were exported from git.
+func loadTemplates(ctx context.Context, source, ref string) (fs.FS, func(), error) {
+	if source == "" {
+		return scaffold.DefaultTemplates(), func() {}, nil
+	}
+
+	// A local directory is used as-is, unless a ref of it is requested
+	if info, err := os.Stat(source); err == nil && info.IsDir() && ref == "" {
+		return os.DirFS(source), func() {}, nil
+	}
+
+	export, err := gitsource.ExportRef(ctx, source, ref, ".")
+	if err != nil {
+		return nil, nil, err
+	}
+	return os.DirFS(export.Dir), func() { export.Close() }, nil
+}
+
+// injectScaffoldDocs generates the documentation of a new module into its
+// README, if the README has the markers for it.
+func injectScaffoldDocs(dir string) error {
+	readme, err := os.ReadFile(filepath.Join(dir, docs.ReadmeName))
+	if err != nil || !strings.Contains(string(readme), docs.BeginMarker) {
+		return nil
+	}
+
+	_, updated, err := docs.Readme(dir)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, docs.ReadmeName), []byte(updated), 0644)
+}
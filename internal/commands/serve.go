@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+
+	"github.com/registry-tools/rt-cli/internal/server"
+)
+
+func ServeCommandFactory() (cli.Command, error) {
+	return &serveCommand{}, nil
+}
+
+type serveCommand struct{}
+
+func (c *serveCommand) Help() string {
+	return `
+Usage: rt serve [options]
+
+  Run a local registry for offline testing. It serves the Terraform module
+  registry protocol, so that "terraform init" can install modules from it,
+  and the Registry Tools API used by "rt publish", storing archives on disk.
+
+  Terraform and rt only reach registries over https. Unless a certificate is
+  provided, a self-signed certificate for localhost is created in the data
+  directory; trust it by setting SSL_CERT_FILE to its path.
+
+Options:
+
+  --dir=<dir>         The directory that modules are stored in. Defaults to
+                      "./registry-data".
+
+  --addr=<address>    The address to listen on. Defaults to "127.0.0.1:8443".
+
+  --token=<token>     Require clients to present this bearer token. By
+                      default, any request is allowed.
+
+  --tls-cert=<path>   A PEM encoded certificate to serve, instead of the
+                      self-signed certificate.
+
+  --tls-key=<path>    The PEM encoded private key of --tls-cert.
+`
+}
+
+func (c *serveCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var dir, addr, token, certFile, keyFile string
+	f.StringVar(&dir, "dir", "./registry-data", "")
+	f.StringVar(&addr, "addr", "127.0.0.1:8443", "")
+	f.StringVar(&token, "token", "", "")
+	f.StringVar(&certFile, "tls-cert", "", "")
+	f.StringVar(&keyFile, "tls-key", "", "")
+
+	if err := f.Parse(args); err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		log.Printf("[ERROR] --tls-cert and --tls-key must be used together")
+		return 1
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("[ERROR] Failed to create data directory: %s", err)
+		return 1
+	}
+
+	var cert tls.Certificate
+	var err error
+	trustPath := ""
+	if certFile != "" {
+		cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	} else {
+		cert, trustPath, err = server.SelfSignedCertificate(filepath.Join(dir, "tls"))
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to load TLS certificate: %s", err)
+		return 1
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("[ERROR] Failed to listen on %s: %s", addr, err)
+		return 1
+	}
+
+	httpServer := &http.Server{
+		Handler:           server.New(dir, token),
+		TLSConfig:         &tls.Config{Certificates: []tls.Certificate{cert}},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	hostname := listener.Addr().String()
+	if host, port, err := net.SplitHostPort(hostname); err == nil && net.ParseIP(host).IsLoopback() {
+		hostname = net.JoinHostPort("localhost", port)
+	}
+
+	info := color.New(color.FgCyan)
+	info.Printf("Serving a local registry at https://%s from %s\n\n", hostname, dir)
+	if trustPath != "" {
+		absPath, _ := filepath.Abs(trustPath)
+		info.Printf("  export SSL_CERT_FILE=%s\n", absPath)
+	}
+	info.Printf("  export REGISTRY_TOOLS_HOSTNAME=%s\n", hostname)
+	if token != "" {
+		info.Printf("  export REGISTRY_TOOLS_TOKEN=%s\n", token)
+	} else {
+		info.Printf("  export REGISTRY_TOOLS_TOKEN=local\n")
+	}
+	info.Printf("\nModules are available to Terraform as %s/<namespace>/<name>/<system>\n", hostname)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if err := httpServer.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("[ERROR] Failed to serve: %s", err)
+		return 1
+	}
+
+	return 0
+}
+
+func (c *serveCommand) Synopsis() string {
+	return "Run a local registry for offline testing"
+}
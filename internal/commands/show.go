@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/provenance"
+	"github.com/registry-tools/rt-cli/internal/signing"
+)
+
+// errVersionNotFound is returned when a module has no such version.
+var errVersionNotFound = errors.New("version not found")
+
+func ShowCommandFactory() (cli.Command, error) {
+	return &showCommand{}, nil
+}
+
+type showCommand struct{}
+
+func (c *showCommand) Help() string {
+	return `
+Usage: rt show <source> --version=<version> [options]
+
+  Show the details of a published module version, Ex:
+  "registrytools.cloud/platform/vpc/aws".
+
+Options:
+
+  --version=<version>   (Required) The version of the module.
+
+  --provenance          Print the SLSA provenance statement attached to the
+                        version by "rt publish --provenance" or by "rt ci publish"
+                        with the provenance input, as JSON.
+`
+}
+
+func (c *showCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var ver string
+	var showProvenance bool
+	f.StringVar(&ver, "version", "", "")
+	f.BoolVar(&showProvenance, "provenance", false, "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if len(positional) != 1 {
+		log.Printf("[ERROR] Expected exactly one module source address")
+		return 1
+	}
+	if ver == "" {
+		log.Printf("[ERROR] Required argument %q is missing", "version")
+		return 1
+	}
+
+	host, mod, err := module.ParseSource(positional[0])
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+	mod.Version = ver
+
+	ctx := context.Background()
+
+	client, err := getAPIClientForHost(ctx, host.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API client: %s", err)
+		return 127
+	}
+
+	published, err := findModuleVersion(ctx, client, mod)
+	if err != nil {
+		log.Printf("[ERROR] Failed to find %s version %s: %s", positional[0], ver, err)
+		return 1
+	}
+
+	if showProvenance {
+		attachment, err := client.GetAttachment(ctx, published.ID, provenance.AttachmentName)
+		if api.IsNotFound(err) {
+			log.Printf("[ERROR] %s version %s has no provenance", positional[0], ver)
+			return 1
+		} else if err != nil {
+			log.Printf("[ERROR] Failed to get provenance: %s", err)
+			return 1
+		}
+
+		fmt.Println(attachment.Content)
+		return 0
+	}
+
+	label := color.New(color.FgCyan, color.Faint)
+	value := color.New(color.FgCyan, color.Bold)
+	field := func(name, v string) {
+		if v != "" {
+			label.Printf("%-11s", name+":")
+			value.Println(v)
+		}
+	}
+
+	field("ID", published.ID)
+	field("Source", mod.Source(host))
+	field("Version", published.Version)
+	field("Published", published.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	if published.Yanked {
+		field("Yanked", "yes")
+	}
+	field("Repository", published.SourceRepository)
+	field("Commit", published.SourceCommit)
+	field("SHA-256", published.ArchiveSHA256)
+	if _, err := client.GetAttachment(ctx, published.ID, signing.AttachmentName); err == nil {
+		field("Signed", "yes")
+	} else if !api.IsNotFound(err) {
+		log.Printf("[WARN] Failed to check for a signature: %s", err)
+	}
+
+	return 0
+}
+
+// findModuleVersion returns the published version of mod with the version
+// mod.Version.
+func findModuleVersion(ctx context.Context, client *api.Client, mod module.Module) (*api.ModuleVersion, error) {
+	versions, err := client.ListModuleVersions(ctx, mod.Namespace, mod.Name, mod.System)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions {
+		if v.Version == mod.Version {
+			return &v, nil
+		}
+	}
+	return nil, errVersionNotFound
+}
+
+func (c *showCommand) Synopsis() string {
+	return "Show the details of a published module version"
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+)
+
+func TokenCreateCommandFactory() (cli.Command, error) {
+	return &tokenCreateCommand{}, nil
+}
+
+func TokenListCommandFactory() (cli.Command, error) {
+	return &tokenListCommand{}, nil
+}
+
+func TokenRevokeCommandFactory() (cli.Command, error) {
+	return &tokenRevokeCommand{}, nil
+}
+
+// stringSliceFlag is a flag that may be repeated or given a comma-separated
+// list of values.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
+
+type tokenCreateCommand struct{}
+
+func (c *tokenCreateCommand) Help() string {
+	return `
+Usage: rt token create [options]
+
+  Create a short-lived token that is restricted to a namespace and a set of
+  scopes, using your current credentials. The token is printed to stdout so
+  that it can be captured by scripts, Ex: TOKEN=$(rt token create ...).
+
+Options:
+
+  --namespace=<namespace>  (Required) The namespace the token is restricted to.
+
+  --scope=<scope>          (Required) A permission granted to the token, Ex:
+                           "publish". May be repeated.
+
+  --ttl=<duration>         How long the token is valid for. Defaults to "1h".
+
+  --description=<text>     A description to help identify the token later.
+`
+}
+
+func (c *tokenCreateCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var opts api.CreateTokenOptions
+	var scopes stringSliceFlag
+	f.StringVar(&opts.Namespace, "namespace", "", "")
+	f.Var(&scopes, "scope", "")
+	f.DurationVar(&opts.TTL, "ttl", time.Hour, "")
+	f.StringVar(&opts.Description, "description", "", "")
+
+	if err := f.Parse(args); err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if opts.Namespace == "" {
+		log.Printf("[ERROR] Required argument %q is missing", "namespace")
+		return 1
+	}
+	if len(scopes) == 0 {
+		log.Printf("[ERROR] Required argument %q is missing", "scope")
+		return 1
+	}
+	opts.Scopes = scopes
+
+	ctx := context.Background()
+	client, err := GetAPIClient(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API client: %s", err)
+		return 127
+	}
+
+	token, err := client.CreateToken(ctx, opts)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create token: %s", err)
+		return 1
+	}
+
+	color.New(color.FgCyan, color.Faint).Fprintf(os.Stderr, "Created token %s for namespace %q with scopes %s, expiring %s\n",
+		token.ID, token.Namespace, strings.Join(token.Scopes, ", "), token.ExpiresAt.Local().Format(time.RFC1123))
+	fmt.Println(token.Token)
+
+	return 0
+}
+
+func (c *tokenCreateCommand) Synopsis() string {
+	return "Create a scoped, short-lived token"
+}
+
+type tokenListCommand struct{}
+
+func (c *tokenListCommand) Help() string {
+	return `
+Usage: rt token list
+
+  List the tokens created with "rt token create" that have not expired or
+  been revoked.
+`
+}
+
+func (c *tokenListCommand) Run(args []string) int {
+	ctx := context.Background()
+	client, err := GetAPIClient(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API client: %s", err)
+		return 127
+	}
+
+	tokens, err := client.ListTokens(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list tokens: %s", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAMESPACE\tSCOPES\tEXPIRES\tDESCRIPTION")
+	for _, token := range tokens {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", token.ID, token.Namespace, strings.Join(token.Scopes, ","),
+			token.ExpiresAt.Local().Format(time.RFC3339), token.Description)
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Printf("[ERROR] Failed to write output: %s", err)
+		return 1
+	}
+
+	return 0
+}
+
+func (c *tokenListCommand) Synopsis() string {
+	return "List scoped tokens"
+}
+
+type tokenRevokeCommand struct{}
+
+func (c *tokenRevokeCommand) Help() string {
+	return `
+Usage: rt token revoke <id>
+
+  Revoke a token created with "rt token create". Use "rt token list" to find
+  the ID of a token.
+`
+}
+
+func (c *tokenRevokeCommand) Run(args []string) int {
+	if len(args) != 1 {
+		log.Printf("[ERROR] Expected exactly one token ID")
+		return 1
+	}
+
+	ctx := context.Background()
+	client, err := GetAPIClient(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API client: %s", err)
+		return 127
+	}
+
+	if err := client.RevokeToken(ctx, args[0]); err != nil {
+		log.Printf("[ERROR] Failed to revoke token: %s", err)
+		return 1
+	}
+
+	color.New(color.FgCyan, color.Faint).Printf("Revoked token %s\n", args[0])
+	return 0
+}
+
+func (c *tokenRevokeCommand) Synopsis() string {
+	return "Revoke a scoped token"
+}
@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/mirror"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/registry"
+	"github.com/registry-tools/rt-cli/internal/signing"
+)
+
+func VerifyCommandFactory() (cli.Command, error) {
+	return &verifyCommand{}, nil
+}
+
+type verifyCommand struct{}
+
+func (c *verifyCommand) Help() string {
+	return `
+Usage: rt verify <source> --version=<version> --public-key=<path>
+
+  Download a published module version, Ex: "registrytools.cloud/platform/vpc/aws",
+  and verify that it was signed by "rt publish --sign-key" with the private key
+  matching the public key. The signature covers the namespace, name, system
+  and version of the module as well as the SHA-256 of its archive.
+
+Options:
+
+  --version=<version>     (Required) The version of the module to verify.
+
+  --public-key=<path>     (Required) The PEM encoded ed25519 public key of the
+                          signing key.
+`
+}
+
+func (c *verifyCommand) Run(args []string) int {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Usage = func() {}
+
+	var ver, publicKeyPath string
+	f.StringVar(&ver, "version", "", "")
+	f.StringVar(&publicKeyPath, "public-key", "", "")
+
+	positional, err := parseInterspersed(f, args)
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+
+	if len(positional) != 1 {
+		log.Printf("[ERROR] Expected exactly one module source address")
+		return 1
+	}
+	for name, value := range map[string]string{"version": ver, "public-key": publicKeyPath} {
+		if value == "" {
+			log.Printf("[ERROR] Required argument %q is missing", name)
+			return 1
+		}
+	}
+
+	host, mod, err := module.ParseSource(positional[0])
+	if err != nil {
+		log.Printf("[ERROR] %s", err)
+		return 1
+	}
+	mod.Version = ver
+
+	publicKey, err := signing.LoadPublicKey(publicKeyPath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load public key: %s", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	registryClient, err := getRegistryClient(ctx, host.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to create registry client: %s", err)
+		return 127
+	}
+
+	apiClient, err := getAPIClientForHost(ctx, host.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API client: %s", err)
+		return 127
+	}
+
+	fetcher := mirror.Fetcher{Tokens: map[string]string{host.String(): registryClient.Token}}
+
+	digest, err := verifyModuleVersion(ctx, registryClient, apiClient, fetcher, mod, publicKey)
+	if err != nil {
+		log.Printf("[ERROR] Failed to verify %s version %s: %s", positional[0], ver, err)
+		return 1
+	}
+
+	color.New(color.FgGreen).Printf("Verified %s version %s\n", positional[0], ver)
+	fmt.Printf("Archive SHA-256: %s\n", digest)
+	return 0
+}
+
+// verifyModuleVersion downloads the archive of a module version and checks it
+// against the digest recorded when it was published and the signature
+// attached to the version. It returns the digest of the downloaded archive.
+func verifyModuleVersion(ctx context.Context, registryClient *registry.Client, apiClient *api.Client, fetcher mirror.Fetcher, mod module.Module, publicKey ed25519.PublicKey) (string, error) {
+	published, err := findModuleVersion(ctx, apiClient, mod)
+	if err != nil {
+		return "", err
+	}
+
+	attachment, err := apiClient.GetAttachment(ctx, published.ID, signing.AttachmentName)
+	if api.IsNotFound(err) {
+		return "", errors.New("the module version was not signed when it was published")
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get signature: %w", err)
+	}
+	signature, err := signing.ParseSignature([]byte(attachment.Content))
+	if err != nil {
+		return "", err
+	}
+
+	location, err := registryClient.DownloadSource(ctx, mod.Namespace, mod.Name, mod.System, mod.Version)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if err := fetcher.Download(ctx, location, hash); err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	if published.ArchiveSHA256 != "" && published.ArchiveSHA256 != digest {
+		return "", fmt.Errorf("the downloaded archive has SHA-256 %s, but %s was published", digest, published.ArchiveSHA256)
+	}
+
+	expected := signingPayload(module.Module{
+		Namespace: published.Namespace,
+		Name:      published.Name,
+		System:    published.System,
+		Version:   published.Version,
+	}, digest)
+	if err := signing.Verify(publicKey, expected, signature); err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// signingPayload returns the payload signed for version mod.Version of mod,
+// with an archive that has the hex encoded SHA-256 digest.
+func signingPayload(mod module.Module, digest string) signing.Payload {
+	return signing.Payload{
+		Namespace:     mod.Namespace,
+		Name:          mod.Name,
+		System:        mod.System,
+		Version:       mod.Version,
+		ArchiveSHA256: digest,
+	}
+}
+
+// uploadSignature attaches signature to the module version with the
+// specified ID.
+func uploadSignature(ctx context.Context, client *api.Client, id string, signature *signing.Signature) error {
+	data, err := signature.JSON()
+	if err != nil {
+		return err
+	}
+
+	return client.CreateAttachment(ctx, id, api.Attachment{
+		Name:      signing.AttachmentName,
+		MediaType: signing.MediaType,
+		Content:   string(data),
+	})
+}
+
+func (c *verifyCommand) Synopsis() string {
+	return "Verify the signature of a published module"
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	sdk "github.com/registry-tools/rt-sdk"
+
+	"github.com/registry-tools/rt-cli/internal/api"
+	"github.com/registry-tools/rt-cli/internal/mirror"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/publish"
+	"github.com/registry-tools/rt-cli/internal/registry"
+	"github.com/registry-tools/rt-cli/internal/server"
+	"github.com/registry-tools/rt-cli/internal/signing"
+)
+
+func TestVerifyModuleVersion(t *testing.T) {
+	srv := httptest.NewTLSServer(server.New(t.TempDir(), ""))
+	t.Cleanup(srv.Close)
+
+	serverURL, _ := url.Parse(srv.URL)
+	client, err := sdk.NewInsecureSDKForTesting(serverURL.Host)
+	if err != nil {
+		t.Fatalf("Failed to create SDK client: %s", err)
+	}
+
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	apiClient := &api.Client{BaseURL: serverURL, HTTPClient: srv.Client()}
+
+	signatures := map[string]*signing.Signature{}
+	publishVersion := func(version string, key ed25519.PrivateKey, signature *signing.Signature) {
+		path, _, err := publish.PackAsFile("../publish/fixtures/moduleA", publish.PackOptions{})
+		if err != nil {
+			t.Fatalf("Failed to pack directory: %s", err)
+		}
+		defer os.Remove(path)
+
+		metadata := publish.Metadata{}
+		metadata.ArchiveSHA256, _ = publish.FileSHA256(path)
+
+		file, _ := os.Open(path)
+		defer file.Close()
+
+		mod := module.Module{Namespace: "platform", Name: "vpc", System: "aws", Version: version}
+		published, err := publish.Publisher{SDK: client, Metadata: metadata}.Publish(context.Background(), mod, file)
+		if err != nil {
+			t.Fatalf("Failed to publish module: %s", err)
+		}
+
+		if key != nil {
+			signature, err = signing.Sign(key, signingPayload(mod, metadata.ArchiveSHA256))
+			if err != nil {
+				t.Fatalf("Failed to sign module: %s", err)
+			}
+		}
+		if signature != nil {
+			if err := uploadSignature(context.Background(), apiClient, published.ID, signature); err != nil {
+				t.Fatalf("Failed to attach signature: %s", err)
+			}
+		}
+		signatures[version] = signature
+	}
+
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	publishVersion("1.0.0", privateKey, nil)
+	publishVersion("1.1.0", otherKey, nil)
+	publishVersion("1.2.0", nil, nil)
+	// The same archive, with the signature of another version
+	publishVersion("1.3.0", nil, signatures["1.0.0"])
+
+	registryClient := &registry.Client{BaseURL: serverURL.JoinPath("v1", "modules"), HTTPClient: srv.Client()}
+	fetcher := mirror.Fetcher{HTTPClient: srv.Client()}
+
+	verify := func(version string) (string, error) {
+		mod := module.Module{Namespace: "platform", Name: "vpc", System: "aws", Version: version}
+		return verifyModuleVersion(context.Background(), registryClient, apiClient, fetcher, mod, publicKey)
+	}
+
+	digest, err := verify("1.0.0")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(digest) != 64 {
+		t.Errorf("expected a SHA-256 digest, got %q", digest)
+	}
+
+	if _, err := verify("1.1.0"); !errors.Is(err, signing.ErrInvalidSignature) {
+		t.Errorf("expected a signature from another key to be rejected, got %v", err)
+	}
+	if _, err := verify("1.2.0"); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("expected an unsigned version to be rejected, got %v", err)
+	}
+	if _, err := verify("1.3.0"); !errors.Is(err, signing.ErrInvalidSignature) {
+		t.Errorf("expected the signature of another version to be rejected, got %v", err)
+	}
+	if _, err := verify("2.0.0"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a missing version to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Package consumers finds and updates the module blocks in Terraform
+// configuration that call a module published to a registry.
+package consumers
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	svchost "github.com/hashicorp/terraform-svchost"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Call is a module block that calls the module.
+type Call struct {
+	// File is the path of the configuration file containing the block.
+	File string
+	Line int
+	// Name is the label of the module block, Ex: "vpc" for module "vpc" {}.
+	Name    string
+	Source  string
+	Version string
+}
+
+// Allows reports whether the version constraint of the call allows v. Calls
+// without a version constraint allow every version.
+func (c Call) Allows(v *version.Version) (bool, error) {
+	if c.Version == "" {
+		return true, nil
+	}
+
+	constraints, err := version.NewConstraint(c.Version)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q: %w", c.Version, err)
+	}
+
+	return constraints.Check(v), nil
+}
+
+// Change is an updated version constraint.
+type Change struct {
+	Call
+	NewVersion string
+}
+
+// ParseError is a configuration file that could not be parsed. Find and
+// Update skip such files, so that one broken file does not hide the module
+// blocks in the rest.
+type ParseError struct {
+	File  string
+	Diags hcl.Diagnostics
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s: %s", e.File, e.Diags.Error())
+}
+
+// SourceMatches reports whether the module source address of a module block
+// refers to the registry module source, Ex: "registrytools.cloud/org/vpc/aws".
+// Hostnames are compared in their normalized form, the rest of the address is
+// compared case-insensitively, and subdirectories of the module are included.
+func SourceMatches(address, source string) bool {
+	address, _, _ = strings.Cut(address, "//")
+
+	addrHost, addrPath, ok := strings.Cut(address, "/")
+	if !ok {
+		return false
+	}
+	srcHost, srcPath, ok := strings.Cut(source, "/")
+	if !ok {
+		return false
+	}
+
+	a, err := svchost.ForComparison(addrHost)
+	if err != nil {
+		return false
+	}
+	b, err := svchost.ForComparison(srcHost)
+	if err != nil {
+		return false
+	}
+
+	return a == b && strings.EqualFold(strings.Trim(addrPath, "/"), strings.Trim(srcPath, "/"))
+}
+
+// configFiles returns the Terraform configuration files in paths, which may be
+// files or directories. Hidden directories, including .terraform, are skipped.
+func configFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if strings.HasSuffix(path, ".tf") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// Find returns the module blocks in the Terraform configuration files under
+// paths that call source, and the files that were skipped because they could
+// not be parsed. Blocks whose source is not a literal string are ignored.
+func Find(paths []string, source string) ([]Call, []*ParseError, error) {
+	files, err := configFiles(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var calls []Call
+	var skipped []*ParseError
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		found, parseErr := findInFile(path, src, source)
+		if parseErr != nil {
+			skipped = append(skipped, parseErr)
+			continue
+		}
+		calls = append(calls, found...)
+	}
+
+	return calls, skipped, nil
+}
+
+func findInFile(path string, src []byte, source string) ([]Call, *ParseError) {
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, &ParseError{File: path, Diags: diags}
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, nil
+	}
+
+	var calls []Call
+	for _, block := range body.Blocks {
+		if block.Type != "module" || len(block.Labels) != 1 {
+			continue
+		}
+
+		address, ok := literalAttribute(block.Body, "source")
+		if !ok || !SourceMatches(address, source) {
+			continue
+		}
+
+		version, _ := literalAttribute(block.Body, "version")
+		calls = append(calls, Call{
+			File:    path,
+			Line:    block.DefRange().Start.Line,
+			Name:    block.Labels[0],
+			Source:  address,
+			Version: version,
+		})
+	}
+
+	return calls, nil
+}
+
+// literalAttribute returns the value of an attribute that is a literal string.
+func literalAttribute(body *hclsyntax.Body, name string) (string, bool) {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return "", false
+	}
+
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+		return "", false
+	}
+
+	return value.AsString(), true
+}
+
+// Update sets the version constraint of the module blocks under paths that
+// call source to version, preserving the formatting of the rest of each file.
+// Blocks without a version argument are not changed, because they use the
+// latest version already. Files are only written if write is true. Like Find,
+// it also returns the files that were skipped because they could not be
+// parsed.
+func Update(paths []string, source, version string, write bool) ([]Change, []*ParseError, error) {
+	calls, skipped, err := Find(paths, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byFile := map[string][]Call{}
+	var order []string
+	for _, call := range calls {
+		if call.Version == "" || call.Version == version {
+			continue
+		}
+		if _, ok := byFile[call.File]; !ok {
+			order = append(order, call.File)
+		}
+		byFile[call.File] = append(byFile[call.File], call)
+	}
+
+	var changes []Change
+	for _, path := range order {
+		if err := updateFile(path, byFile[path], version, write); err != nil {
+			return changes, skipped, err
+		}
+		for _, call := range byFile[path] {
+			changes = append(changes, Change{Call: call, NewVersion: version})
+		}
+	}
+
+	return changes, skipped, nil
+}
+
+func updateFile(path string, calls []Call, version string, write bool) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	file, diags := hclwrite.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+	}
+
+	names := map[string]bool{}
+	for _, call := range calls {
+		names[call.Name] = true
+	}
+
+	for _, block := range file.Body().Blocks() {
+		labels := block.Labels()
+		if block.Type() != "module" || len(labels) != 1 || !names[labels[0]] {
+			continue
+		}
+		block.Body().SetAttributeValue("version", cty.StringVal(version))
+	}
+
+	if !write {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, file.Bytes(), info.Mode().Perm())
+}
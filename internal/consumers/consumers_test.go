@@ -0,0 +1,186 @@
+package consumers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/hashicorp/go-version"
+)
+
+const vpcSource = "registrytools.cloud/platform/vpc/aws"
+
+// copyFixture copies the consumer fixture into a temporary directory so that
+// it can be updated.
+func copyFixture(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	err := filepath.WalkDir("fixtures/consumer", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel("fixtures/consumer", path)
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), data, 0644)
+	})
+	if err != nil {
+		t.Fatalf("failed to copy fixture: %s", err)
+	}
+	return dir
+}
+
+func TestSourceMatches(t *testing.T) {
+	items := map[string]bool{
+		"registrytools.cloud/platform/vpc/aws":                 true,
+		"RegistryTools.Cloud/Platform/VPC/aws":                 true,
+		"registrytools.cloud/platform/vpc/aws//modules/subnet": true,
+		"registrytools.cloud/platform/vpc/gcp":                 false,
+		"example.com/platform/vpc/aws":                         false,
+		"./modules/vpc":                                        false,
+		"platform/vpc/aws":                                     false,
+	}
+
+	for address, expected := range items {
+		if actual := SourceMatches(address, vpcSource); actual != expected {
+			t.Errorf("expected SourceMatches(%q) to be %v", address, expected)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	calls, skipped, err := Find([]string{"fixtures/consumer"}, vpcSource)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("expected no skipped files, got %v", skipped)
+	}
+
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 calls, got %+v", calls)
+	}
+
+	first := calls[0]
+	if first.Name != "vpc" || first.Line != 2 || first.Version != "~> 1.2" || first.File != filepath.Join("fixtures/consumer", "main.tf") {
+		t.Errorf("unexpected call %+v", first)
+	}
+	if calls[2].Name != "unpinned" || calls[2].Version != "" {
+		t.Errorf("unexpected call %+v", calls[2])
+	}
+}
+
+func TestFindSkipsInvalidFiles(t *testing.T) {
+	dir := copyFixture(t)
+	broken := filepath.Join(dir, "broken.tf")
+	if err := os.WriteFile(broken, []byte("module \"vpc\" {\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	calls, skipped, err := Find([]string{dir}, vpcSource)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(calls) != 4 {
+		t.Errorf("expected the calls in the other files, got %+v", calls)
+	}
+	if len(skipped) != 1 || skipped[0].File != broken {
+		t.Errorf("expected %s to be skipped, got %v", broken, skipped)
+	}
+}
+
+func TestCallAllows(t *testing.T) {
+	latest := version.Must(version.NewVersion("1.4.0"))
+	items := map[string]bool{
+		"":       true,
+		"~> 1.2": true,
+		"1.3.0":  false,
+		">= 2.0": false,
+	}
+
+	for constraint, expected := range items {
+		actual, err := Call{Version: constraint}.Allows(latest)
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %s", constraint, err)
+		}
+		if actual != expected {
+			t.Errorf("expected %q to allow 1.4.0: %v", constraint, expected)
+		}
+	}
+
+	if _, err := (Call{Version: "latest"}).Allows(latest); err == nil {
+		t.Error("expected an error for an invalid constraint")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := copyFixture(t)
+
+	changes, _, err := Update([]string{dir}, vpcSource, "1.3.0", true)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	// The nested module already uses 1.3.0 and the unpinned module is left alone
+	if len(changes) != 2 || changes[0].Name != "vpc" || changes[1].Name != "subnets" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+
+	actual, _ := os.ReadFile(filepath.Join(dir, "main.tf"))
+	expected := `# The network for the application
+module "vpc" {
+  source  = "registrytools.cloud/platform/vpc/aws"
+  version = "1.3.0" # pinned to the 1.x series
+
+  cidr_block = "10.0.0.0/16"
+}
+
+module "subnets" {
+  source  = "REGISTRYTOOLS.cloud/platform/vpc/aws//modules/subnets"
+  version = "1.3.0"
+}
+
+module "unpinned" {
+  source = "registrytools.cloud/platform/vpc/aws"
+}
+
+module "other" {
+  source  = "registrytools.cloud/platform/dns/aws"
+  version = "1.0.0"
+}
+`
+	if string(actual) != expected {
+		t.Errorf("unexpected update:\n%v", diff.LineDiff(string(actual), expected))
+	}
+
+	ignored, _ := os.ReadFile(filepath.Join(dir, ".terraform", "ignored.tf"))
+	original, _ := os.ReadFile(filepath.Join("fixtures", "consumer", ".terraform", "ignored.tf"))
+	if string(ignored) != string(original) {
+		t.Error("expected files in hidden directories to be ignored")
+	}
+}
+
+func TestUpdateDryRun(t *testing.T) {
+	dir := copyFixture(t)
+	before, _ := os.ReadFile(filepath.Join(dir, "main.tf"))
+
+	changes, _, err := Update([]string{dir}, vpcSource, "2.0.0", false)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(changes) != 3 {
+		t.Errorf("expected 3 changes, got %+v", changes)
+	}
+
+	after, _ := os.ReadFile(filepath.Join(dir, "main.tf"))
+	if string(before) != string(after) {
+		t.Error("expected files not to be written")
+	}
+}
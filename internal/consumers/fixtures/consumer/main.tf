@@ -0,0 +1,21 @@
+# The network for the application
+module "vpc" {
+  source  = "registrytools.cloud/platform/vpc/aws"
+  version = "~> 1.2" # pinned to the 1.x series
+
+  cidr_block = "10.0.0.0/16"
+}
+
+module "subnets" {
+  source  = "REGISTRYTOOLS.cloud/platform/vpc/aws//modules/subnets"
+  version = "1.2.0"
+}
+
+module "unpinned" {
+  source = "registrytools.cloud/platform/vpc/aws"
+}
+
+module "other" {
+  source  = "registrytools.cloud/platform/dns/aws"
+  version = "1.0.0"
+}
@@ -0,0 +1,4 @@
+module "vpc" {
+  source  = "registrytools.cloud/platform/vpc/aws"
+  version = "1.3.0"
+}
@@ -0,0 +1,152 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/cli/oauth"
+	svchost "github.com/hashicorp/terraform-svchost"
+	"github.com/hashicorp/terraform-svchost/disco"
+
+	"github.com/registry-tools/rt-cli/version"
+)
+
+const (
+	// LoginServiceID is the OAuth client configuration used by `rt login`.
+	LoginServiceID = "login.v1"
+	// ModulesServiceID is the Terraform module registry protocol.
+	ModulesServiceID = "modules.v1"
+	// APIServiceID is the Registry Tools API, used to publish modules.
+	APIServiceID = "rt.v1"
+)
+
+// Login describes the OAuth endpoints a host uses to authenticate the CLI.
+type Login struct {
+	Host oauth.Host
+
+	// MinPort and MaxPort restrict the loopback ports that may be used as the
+	// redirect URI of the authorization code flow. Both are zero if the host
+	// does not restrict them.
+	MinPort, MaxPort uint16
+}
+
+// Services resolves the endpoints of Registry Tools services for a hostname
+// using Terraform-style service discovery. Discovery documents are fetched
+// once per host and cached for the lifetime of the Services value.
+type Services struct {
+	disco *disco.Disco
+}
+
+// Default is shared by all commands so that each host is only discovered once
+// per invocation.
+var Default = New()
+
+// New returns a Services that uses the default HTTP transport.
+func New() *Services {
+	d := disco.New()
+	d.SetUserAgent("rt-cli/" + version.Version)
+
+	return &Services{disco: d}
+}
+
+// NewWithTransport returns a Services that makes discovery requests using the
+// specified transport.
+func NewWithTransport(transport http.RoundTripper) *Services {
+	s := New()
+	s.disco.Transport = transport
+	return s
+}
+
+func (s *Services) discover(hostname string) (*disco.Host, svchost.Hostname, error) {
+	host, err := svchost.ForComparison(hostname)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid hostname %q: %w", hostname, err)
+	}
+
+	services, err := s.disco.Discover(host)
+	if err != nil {
+		return nil, host, fmt.Errorf("service discovery for %s failed: %w", host.ForDisplay(), err)
+	}
+
+	return services, host, nil
+}
+
+func isNotProvided(err error) bool {
+	var notProvided *disco.ErrServiceNotProvided
+	return errors.As(err, &notProvided)
+}
+
+// APIURL returns the base URL of the Registry Tools API for hostname. Hosts
+// that do not advertise rt.v1 are assumed to serve the API at their root.
+func (s *Services) APIURL(hostname string) (*url.URL, error) {
+	services, host, err := s.discover(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := services.ServiceURL(APIServiceID)
+	if isNotProvided(err) {
+		log.Printf("[TRACE] %s does not advertise %s, using the host root", host.ForDisplay(), APIServiceID)
+		return &url.URL{Scheme: "https", Host: host.String(), Path: "/"}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// ModulesURL returns the base URL of the Terraform module registry protocol
+// for hostname. Unlike the other services, it has no default location.
+func (s *Services) ModulesURL(hostname string) (*url.URL, error) {
+	services, _, err := s.discover(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.ServiceURL(ModulesServiceID)
+}
+
+// Login returns the OAuth endpoints used to log in to hostname. Hosts that do
+// not advertise login.v1 are assumed to use the Registry Tools Cloud paths.
+//
+// login.v1 has no property for the device authorization endpoint, so it is
+// resolved as "device/code" relative to the token endpoint.
+func (s *Services) Login(hostname string) (*Login, error) {
+	services, host, err := s.discover(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := services.ServiceOAuthClient(LoginServiceID)
+	if isNotProvided(err) {
+		log.Printf("[TRACE] %s does not advertise %s, using default login endpoints", host.ForDisplay(), LoginServiceID)
+		return &Login{
+			Host: oauth.Host{
+				DeviceCodeURL: "https://" + host.String() + "/auth/device/code",
+				TokenURL:      "https://" + host.String() + "/auth/token",
+				AuthorizeURL:  "https://" + host.String() + "/login",
+			},
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	login := &Login{
+		MinPort: client.MinPort,
+		MaxPort: client.MaxPort,
+	}
+
+	if client.AuthorizationURL != nil {
+		login.Host.AuthorizeURL = client.AuthorizationURL.String()
+	}
+
+	if client.TokenURL != nil {
+		login.Host.TokenURL = client.TokenURL.String()
+		login.Host.DeviceCodeURL = client.TokenURL.ResolveReference(&url.URL{Path: "device/code"}).String()
+	}
+
+	return login, nil
+}
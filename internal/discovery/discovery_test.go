@@ -0,0 +1,119 @@
+package discovery_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/registry-tools/rt-cli/internal/discovery"
+)
+
+func newTestServer(t *testing.T, document string) (*httptest.Server, *int) {
+	t.Helper()
+
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(res http.ResponseWriter, req *http.Request) {
+		requests++
+		if document == "" {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(document))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func hostOf(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %s", err)
+	}
+	return u.Host
+}
+
+func TestDiscoveredServices(t *testing.T) {
+	server, requests := newTestServer(t, `{
+		"rt.v1": "https://api.example.com/",
+		"modules.v1": "/registry/modules/",
+		"login.v1": {
+			"client": "terraform-cli",
+			"grant_types": ["authz_code"],
+			"authz": "/oauth/authorize",
+			"token": "/oauth/token",
+			"ports": [10000, 10010]
+		}
+	}`)
+	host := hostOf(t, server)
+	services := discovery.NewWithTransport(server.Client().Transport)
+
+	apiURL, err := services.APIURL(host)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if apiURL.String() != "https://api.example.com/" {
+		t.Errorf("unexpected API URL %q", apiURL)
+	}
+
+	modulesURL, err := services.ModulesURL(host)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if expected := "https://" + host + "/registry/modules/"; modulesURL.String() != expected {
+		t.Errorf("expected modules URL %q, got %q", expected, modulesURL)
+	}
+
+	login, err := services.Login(host)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if expected := "https://" + host + "/oauth/authorize"; login.Host.AuthorizeURL != expected {
+		t.Errorf("expected authorize URL %q, got %q", expected, login.Host.AuthorizeURL)
+	}
+	if expected := "https://" + host + "/oauth/token"; login.Host.TokenURL != expected {
+		t.Errorf("expected token URL %q, got %q", expected, login.Host.TokenURL)
+	}
+	if expected := "https://" + host + "/oauth/device/code"; login.Host.DeviceCodeURL != expected {
+		t.Errorf("expected device code URL %q, got %q", expected, login.Host.DeviceCodeURL)
+	}
+	if login.MinPort != 10000 || login.MaxPort != 10010 {
+		t.Errorf("unexpected port range %d-%d", login.MinPort, login.MaxPort)
+	}
+
+	if *requests != 1 {
+		t.Errorf("expected discovery to be cached after 1 request, got %d requests", *requests)
+	}
+}
+
+func TestDefaultServices(t *testing.T) {
+	server, _ := newTestServer(t, "")
+	host := hostOf(t, server)
+	services := discovery.NewWithTransport(server.Client().Transport)
+
+	apiURL, err := services.APIURL(host)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if expected := "https://" + host + "/"; apiURL.String() != expected {
+		t.Errorf("expected API URL %q, got %q", expected, apiURL)
+	}
+
+	login, err := services.Login(host)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if expected := "https://" + host + "/auth/device/code"; login.Host.DeviceCodeURL != expected {
+		t.Errorf("expected device code URL %q, got %q", expected, login.Host.DeviceCodeURL)
+	}
+
+	if _, err := services.ModulesURL(host); err == nil {
+		t.Error("expected an error when modules.v1 is not advertised")
+	}
+}
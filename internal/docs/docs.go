@@ -0,0 +1,316 @@
+// Package docs generates Markdown documentation of a module's inputs,
+// outputs, requirements, resources and submodules from its configuration.
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// BeginMarker and EndMarker surround the generated content in a README.
+	BeginMarker = "<!-- BEGIN_RT_DOCS -->"
+	EndMarker   = "<!-- END_RT_DOCS -->"
+)
+
+// Variable is an input variable of a module.
+type Variable struct {
+	Name        string
+	Description string
+	// Type and Default are the source text of the expressions. Default is
+	// empty when the variable is required.
+	Type      string
+	Default   string
+	Required  bool
+	Sensitive bool
+}
+
+// Output is an output value of a module.
+type Output struct {
+	Name        string
+	Description string
+	Sensitive   bool
+}
+
+// Requirement is a version constraint on Terraform or a provider.
+type Requirement struct {
+	Name    string
+	Source  string
+	Version string
+}
+
+// Resource is a managed resource or data source.
+type Resource struct {
+	Type string
+	Name string
+	Data bool
+}
+
+// ModuleCall is a submodule called by a module.
+type ModuleCall struct {
+	Name    string
+	Source  string
+	Version string
+}
+
+// Module is the documented interface of a module.
+type Module struct {
+	Variables    []Variable
+	Outputs      []Output
+	Requirements []Requirement
+	Resources    []Resource
+	Modules      []ModuleCall
+}
+
+var (
+	rootSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "terraform"},
+			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "output", LabelNames: []string{"name"}},
+			{Type: "resource", LabelNames: []string{"type", "name"}},
+			{Type: "data", LabelNames: []string{"type", "name"}},
+			{Type: "module", LabelNames: []string{"name"}},
+		},
+	}
+	terraformSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "required_version"}},
+		Blocks:     []hcl.BlockHeaderSchema{{Type: "required_providers"}},
+	}
+	variableSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "description"}, {Name: "type"}, {Name: "default"}, {Name: "sensitive"}},
+	}
+	outputSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "description"}, {Name: "sensitive"}},
+	}
+	moduleSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "source"}, {Name: "version"}},
+	}
+)
+
+// Load reads the configuration files in dir. Nested directories are not
+// read; they are documented separately.
+func Load(dir string) (*Module, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loader{parser: hclparse.NewParser(), module: &Module{}}
+	found := false
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json")) {
+			continue
+		}
+		found = true
+		if err := l.loadFile(filepath.Join(dir, name)); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no .tf or .tf.json files found in %s", dir)
+	}
+
+	l.sort()
+	return l.module, nil
+}
+
+type loader struct {
+	parser *hclparse.Parser
+	module *Module
+	src    []byte
+}
+
+func (l *loader) loadFile(path string) error {
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = l.parser.ParseJSONFile(path)
+	} else {
+		file, diags = l.parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return errors.New(diags.Error())
+	}
+	l.src = file.Bytes
+
+	content, _, diags := file.Body.PartialContent(rootSchema)
+	if diags.HasErrors() {
+		return errors.New(diags.Error())
+	}
+
+	for _, block := range content.Blocks {
+		if err := l.loadBlock(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *loader) loadBlock(block *hcl.Block) error {
+	switch block.Type {
+	case "terraform":
+		return l.loadTerraform(block)
+	case "variable":
+		attrs, err := blockAttributes(block, variableSchema)
+		if err != nil {
+			return err
+		}
+		v := Variable{
+			Name:        block.Labels[0],
+			Description: l.stringValue(attrs["description"]),
+			Type:        l.text(attrs["type"]),
+			Default:     l.text(attrs["default"]),
+			Required:    attrs["default"] == nil,
+			Sensitive:   l.boolValue(attrs["sensitive"]),
+		}
+		if v.Type == "" {
+			v.Type = "any"
+		}
+		l.module.Variables = append(l.module.Variables, v)
+	case "output":
+		attrs, err := blockAttributes(block, outputSchema)
+		if err != nil {
+			return err
+		}
+		l.module.Outputs = append(l.module.Outputs, Output{
+			Name:        block.Labels[0],
+			Description: l.stringValue(attrs["description"]),
+			Sensitive:   l.boolValue(attrs["sensitive"]),
+		})
+	case "resource", "data":
+		l.module.Resources = append(l.module.Resources, Resource{
+			Type: block.Labels[0],
+			Name: block.Labels[1],
+			Data: block.Type == "data",
+		})
+	case "module":
+		attrs, err := blockAttributes(block, moduleSchema)
+		if err != nil {
+			return err
+		}
+		l.module.Modules = append(l.module.Modules, ModuleCall{
+			Name:    block.Labels[0],
+			Source:  l.stringValue(attrs["source"]),
+			Version: l.stringValue(attrs["version"]),
+		})
+	}
+	return nil
+}
+
+func (l *loader) loadTerraform(block *hcl.Block) error {
+	content, _, diags := block.Body.PartialContent(terraformSchema)
+	if diags.HasErrors() {
+		return errors.New(diags.Error())
+	}
+
+	if version := l.stringValue(content.Attributes["required_version"]); version != "" {
+		l.module.Requirements = append(l.module.Requirements, Requirement{Name: "terraform", Version: version})
+	}
+
+	for _, providers := range content.Blocks {
+		attrs, diags := providers.Body.JustAttributes()
+		if diags.HasErrors() {
+			return errors.New(diags.Error())
+		}
+
+		for name, attr := range attrs {
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				continue
+			}
+
+			requirement := Requirement{Name: name}
+			switch {
+			case value.Type() == cty.String:
+				requirement.Version = value.AsString()
+			case value.Type().IsObjectType():
+				requirement.Source = objectString(value, "source")
+				requirement.Version = objectString(value, "version")
+			}
+			l.module.Requirements = append(l.module.Requirements, requirement)
+		}
+	}
+	return nil
+}
+
+func (l *loader) sort() {
+	m := l.module
+	sort.Slice(m.Variables, func(i, j int) bool { return m.Variables[i].Name < m.Variables[j].Name })
+	sort.Slice(m.Outputs, func(i, j int) bool { return m.Outputs[i].Name < m.Outputs[j].Name })
+	sort.Slice(m.Modules, func(i, j int) bool { return m.Modules[i].Name < m.Modules[j].Name })
+	sort.Slice(m.Resources, func(i, j int) bool { return m.Resources[i].address() < m.Resources[j].address() })
+	// Terraform itself is listed first
+	sort.Slice(m.Requirements, func(i, j int) bool {
+		if (m.Requirements[i].Name == "terraform") != (m.Requirements[j].Name == "terraform") {
+			return m.Requirements[i].Name == "terraform"
+		}
+		return m.Requirements[i].Name < m.Requirements[j].Name
+	})
+}
+
+func blockAttributes(block *hcl.Block, schema *hcl.BodySchema) (hcl.Attributes, error) {
+	content, _, diags := block.Body.PartialContent(schema)
+	if diags.HasErrors() {
+		return nil, errors.New(diags.Error())
+	}
+	return content.Attributes, nil
+}
+
+// text returns the source text of an attribute's expression.
+func (l *loader) text(attr *hcl.Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	rng := attr.Expr.Range()
+	return string(rng.SliceBytes(l.src))
+}
+
+func (l *loader) stringValue(attr *hcl.Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+		return ""
+	}
+	return value.AsString()
+}
+
+func (l *loader) boolValue(attr *hcl.Attribute) bool {
+	if attr == nil {
+		return false
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.Bool {
+		return false
+	}
+	return value.True()
+}
+
+func objectString(value cty.Value, name string) string {
+	if !value.Type().HasAttribute(name) {
+		return ""
+	}
+	attr := value.GetAttr(name)
+	if attr.IsNull() || !attr.IsKnown() || attr.Type() != cty.String {
+		return ""
+	}
+	return attr.AsString()
+}
+
+func (r Resource) address() string {
+	if r.Data {
+		return "data." + r.Type + "." + r.Name
+	}
+	return r.Type + "." + r.Name
+}
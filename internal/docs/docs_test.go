@@ -0,0 +1,94 @@
+package docs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	m, err := Load("./fixtures/module")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := "## Requirements\n\n" +
+		"| Name | Source | Version |\n|------|--------|---------|\n" +
+		"| terraform |  | `>= 1.5` |\n" +
+		"| aws | hashicorp/aws | `~> 5.0` |\n\n" +
+		"## Modules\n\n" +
+		"| Name | Source | Version |\n|------|--------|---------|\n" +
+		"| subnets | registrytools.cloud/platform/subnets/aws | `>= 1.0` |\n\n" +
+		"## Resources\n\n" +
+		"| Name | Type |\n|------|------|\n" +
+		"| aws_vpc.this | resource |\n" +
+		"| data.aws_availability_zones.available | data source |\n\n" +
+		"## Inputs\n\n" +
+		"| Name | Description | Type | Default | Required |\n|------|-------------|------|---------|:--------:|\n" +
+		"| cidr | The CIDR block of the VPC. | `string` | n/a | yes |\n" +
+		"| password |  | `string` | (sensitive) | no |\n" +
+		"| tags | Tags to add to every resource \\| including the VPC. | `object({ team = string })` | `{ team = \"platform\" }` | no |\n\n" +
+		"## Outputs\n\n" +
+		"| Name | Description |\n|------|-------------|\n" +
+		"| arn | (sensitive) |\n" +
+		"| vpc_id | The ID of the VPC. |\n"
+
+	if actual := m.Markdown(); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestLoadEmptyDirectory(t *testing.T) {
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without configuration files")
+	}
+}
+
+func TestInject(t *testing.T) {
+	cases := map[string]struct {
+		readme   string
+		expected string
+	}{
+		"empty":  {"", BeginMarker + "\nnew\n" + EndMarker + "\n"},
+		"append": {"# VPC\n\nIntro", "# VPC\n\nIntro\n\n" + BeginMarker + "\nnew\n" + EndMarker + "\n"},
+		"replace": {
+			"# VPC\n\n" + BeginMarker + "\nold\n" + EndMarker + "\n\n## License\n",
+			"# VPC\n\n" + BeginMarker + "\nnew\n" + EndMarker + "\n\n## License\n",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := Inject(c.readme, "new\n")
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+			if actual != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, actual)
+			}
+
+			// Injecting again doesn't change anything
+			again, _ := Inject(actual, "new\n")
+			if again != actual {
+				t.Errorf("expected injecting to be idempotent, got %q", again)
+			}
+		})
+	}
+
+	if _, err := Inject(EndMarker+"\n"+BeginMarker, "new"); !errors.Is(err, ErrMarkers) {
+		t.Errorf("expected a marker error, got %v", err)
+	}
+}
+
+func TestReadme(t *testing.T) {
+	current, updated, err := Readme("./fixtures/module")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if current != "" {
+		t.Errorf("expected no README, got %q", current)
+	}
+	if !strings.HasPrefix(updated, BeginMarker+"\n## Requirements") {
+		t.Errorf("unexpected README %q", updated)
+	}
+}
@@ -0,0 +1,22 @@
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+  tags       = var.tags
+}
+
+data "aws_availability_zones" "available" {}
+
+module "subnets" {
+  source  = "registrytools.cloud/platform/subnets/aws"
+  version = ">= 1.0"
+}
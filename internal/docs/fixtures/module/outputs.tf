@@ -0,0 +1,9 @@
+output "vpc_id" {
+  description = "The ID of the VPC."
+  value       = aws_vpc.this.id
+}
+
+output "arn" {
+  value     = aws_vpc.this.arn
+  sensitive = true
+}
@@ -0,0 +1,20 @@
+variable "cidr" {
+  description = "The CIDR block of the VPC."
+  type        = string
+}
+
+variable "tags" {
+  description = "Tags to add to every resource | including the VPC."
+  type = object({
+    team = string
+  })
+  default = {
+    team = "platform"
+  }
+}
+
+variable "password" {
+  type      = string
+  default   = "hunter2"
+  sensitive = true
+}
@@ -0,0 +1,143 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrMarkers is returned by Inject when the README does not have the begin
+// marker followed by the end marker.
+var ErrMarkers = fmt.Errorf("the README must have a %s comment followed by a %s comment", BeginMarker, EndMarker)
+
+// Markdown returns the documentation of the module as Markdown.
+func (m *Module) Markdown() string {
+	var b strings.Builder
+
+	if len(m.Requirements) > 0 {
+		b.WriteString("## Requirements\n\n| Name | Source | Version |\n|------|--------|---------|\n")
+		for _, r := range m.Requirements {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", cell(r.Name), cell(r.Source), code(r.Version))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.Modules) > 0 {
+		b.WriteString("## Modules\n\n| Name | Source | Version |\n|------|--------|---------|\n")
+		for _, c := range m.Modules {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", cell(c.Name), cell(c.Source), code(c.Version))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.Resources) > 0 {
+		b.WriteString("## Resources\n\n| Name | Type |\n|------|------|\n")
+		for _, r := range m.Resources {
+			kind := "resource"
+			if r.Data {
+				kind = "data source"
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", cell(r.address()), kind)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Inputs\n\n")
+	if len(m.Variables) == 0 {
+		b.WriteString("No inputs.\n\n")
+	} else {
+		b.WriteString("| Name | Description | Type | Default | Required |\n|------|-------------|------|---------|:--------:|\n")
+		for _, v := range m.Variables {
+			def, required := "n/a", "yes"
+			if !v.Required {
+				def, required = code(v.Default), "no"
+			}
+			if v.Sensitive && !v.Required {
+				def = "(sensitive)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", cell(v.Name), cell(v.Description), code(v.Type), def, required)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Outputs\n\n")
+	if len(m.Outputs) == 0 {
+		b.WriteString("No outputs.\n")
+	} else {
+		b.WriteString("| Name | Description |\n|------|-------------|\n")
+		for _, o := range m.Outputs {
+			description := o.Description
+			if o.Sensitive {
+				description = strings.TrimSpace(description + " (sensitive)")
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", cell(o.Name), cell(description))
+		}
+	}
+
+	return b.String()
+}
+
+// cell escapes text for a Markdown table cell.
+func cell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// code formats an expression as inline code in a Markdown table cell.
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + cell(s) + "`"
+}
+
+// Inject replaces the content between the markers in readme with generated.
+// If readme has neither marker, the generated content and markers are
+// appended to it.
+func Inject(readme, generated string) (string, error) {
+	block := BeginMarker + "\n" + strings.TrimRight(generated, "\n") + "\n" + EndMarker
+
+	begin := strings.Index(readme, BeginMarker)
+	end := strings.Index(readme, EndMarker)
+
+	switch {
+	case begin < 0 && end < 0:
+		if readme != "" && !strings.HasSuffix(readme, "\n") {
+			readme += "\n"
+		}
+		if readme != "" {
+			readme += "\n"
+		}
+		return readme + block + "\n", nil
+	case begin < 0 || end < begin:
+		return "", ErrMarkers
+	}
+
+	return readme[:begin] + block + readme[end+len(EndMarker):], nil
+}
+
+// ReadmeName is the name of the README that documentation is injected into.
+const ReadmeName = "README.md"
+
+// Readme returns the current README of the module in dir, which is empty if
+// there is none, and the README with up to date documentation injected.
+func Readme(dir string) (string, string, error) {
+	m, err := Load(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	current, err := os.ReadFile(filepath.Join(dir, ReadmeName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", "", err
+	}
+
+	updated, err := Inject(string(current), m.Markdown())
+	if err != nil {
+		return "", "", err
+	}
+	return string(current), updated, nil
+}
@@ -0,0 +1,289 @@
+// Package examples tests the examples of a module with a locally installed
+// terraform binary before the module is published.
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	svchost "github.com/hashicorp/terraform-svchost"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/registry-tools/rt-cli/internal/module"
+)
+
+// ErrTerraformNotFound is returned when terraform is not installed.
+var ErrTerraformNotFound = errors.New("terraform was not found")
+
+// Step is a terraform command run against an example.
+type Step string
+
+const (
+	StepInit     Step = "init"
+	StepValidate Step = "validate"
+	StepFmt      Step = "fmt"
+)
+
+// Result is the outcome of running a step against an example.
+type Result struct {
+	// Example is relative to the module directory, Ex: "examples/basic".
+	Example string
+	Step    Step
+	// Output is the combined output of terraform.
+	Output string
+	// Err is nil if the step succeeded.
+	Err error
+}
+
+// Results are the outcomes of testing every example.
+type Results []Result
+
+// Failed reports whether any step failed.
+func (r Results) Failed() bool {
+	for _, result := range r {
+		if result.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Runner runs terraform against the examples of a module.
+type Runner struct {
+	// Terraform is the path of the terraform binary.
+	Terraform string
+}
+
+// NewRunner returns a Runner for the terraform binary at path, or the one in
+// PATH if path is empty. It returns ErrTerraformNotFound if there is none.
+func NewRunner(path string) (*Runner, error) {
+	if path == "" {
+		path = "terraform"
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTerraformNotFound, err)
+	}
+	return &Runner{Terraform: resolved}, nil
+}
+
+// Find returns the directories in the examples directory of the module in
+// dir that contain configuration files, relative to dir.
+func Find(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "examples"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, "examples", entry.Name(), "*.tf"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) > 0 {
+			found = append(found, path.Join("examples", entry.Name()))
+		}
+	}
+	return found, nil
+}
+
+// Test runs "terraform fmt -check" against each example in the module
+// directory, then copies the module to a temporary directory, rewrites the
+// examples' calls of mod in the registry at host to the local copy, and runs
+// "terraform init -backend=false" and "terraform validate" against them.
+// Every example is tested, even after a failure.
+func (r *Runner) Test(ctx context.Context, dir string, host svchost.Hostname, mod module.Module) (Results, error) {
+	found, err := Find(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find examples: %w", err)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	work, err := os.MkdirTemp("", "rt-examples-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	if err := copyModule(dir, work); err != nil {
+		return nil, fmt.Errorf("failed to copy module: %w", err)
+	}
+
+	var results Results
+	for _, example := range found {
+		// Check the formatting of the original files, which rewriting may
+		// change
+		results = append(results, r.run(ctx, filepath.Join(dir, filepath.FromSlash(example)), example, StepFmt, "fmt", "-check", "-diff", "-no-color"))
+
+		exampleDir := filepath.Join(work, filepath.FromSlash(example))
+		if err := rewriteExample(exampleDir, work, host, mod); err != nil {
+			results = append(results, Result{Example: example, Step: StepInit, Err: fmt.Errorf("failed to rewrite module sources: %w", err)})
+			continue
+		}
+
+		result := r.run(ctx, exampleDir, example, StepInit, "init", "-backend=false", "-input=false", "-no-color")
+		results = append(results, result)
+		if result.Err != nil {
+			continue
+		}
+		results = append(results, r.run(ctx, exampleDir, example, StepValidate, "validate", "-no-color"))
+	}
+	return results, nil
+}
+
+func (r *Runner) run(ctx context.Context, dir, example string, step Step, args ...string) Result {
+	cmd := exec.CommandContext(ctx, r.Terraform, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	output, err := cmd.CombinedOutput()
+	return Result{Example: example, Step: step, Output: string(output), Err: err}
+}
+
+// rewriteExample rewrites the source of module blocks in the example's
+// configuration files that call mod in the registry at host to the path of
+// the module root, removing their version constraint.
+func rewriteExample(exampleDir, root string, host svchost.Hostname, mod module.Module) error {
+	matches, err := filepath.Glob(filepath.Join(exampleDir, "*.tf"))
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(exampleDir, root)
+	if err != nil {
+		return err
+	}
+	local := filepath.ToSlash(rel)
+
+	for _, match := range matches {
+		src, err := os.ReadFile(match)
+		if err != nil {
+			return err
+		}
+		updated, changed, err := Rewrite(src, filepath.Base(match), local, host, mod)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := os.WriteFile(match, updated, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces the source of module blocks in src that call mod in the
+// registry at host with the local path of the module, Ex: "../..", keeping
+// any subdirectory, and removes their version constraint. It reports whether
+// any block was changed.
+func Rewrite(src []byte, filename, local string, host svchost.Hostname, mod module.Module) ([]byte, bool, error) {
+	file, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false, errors.New(diags.Error())
+	}
+
+	changed := false
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "module" {
+			continue
+		}
+		attr := block.Body().GetAttribute("source")
+		if attr == nil {
+			continue
+		}
+
+		source, ok := literalString(attr.Expr().BuildTokens(nil))
+		if !ok {
+			continue
+		}
+		_, subdir, _ := strings.Cut(source, "//")
+		callHost, call, err := module.ParseSource(source)
+		if err != nil || callHost != host || !sameModule(call, mod) {
+			continue
+		}
+
+		target := local
+		if subdir != "" {
+			target = path.Join(local, subdir)
+		}
+		if !strings.HasPrefix(target, ".") {
+			target = "./" + target
+		}
+		block.Body().SetAttributeValue("source", cty.StringVal(target))
+		block.Body().RemoveAttribute("version")
+		changed = true
+	}
+
+	if !changed {
+		return src, false, nil
+	}
+	return file.Bytes(), true, nil
+}
+
+func sameModule(a, b module.Module) bool {
+	return strings.EqualFold(a.Namespace, b.Namespace) &&
+		strings.EqualFold(a.Name, b.Name) &&
+		strings.EqualFold(a.System, b.System)
+}
+
+// literalString returns the value of a quoted string without interpolation.
+func literalString(tokens hclwrite.Tokens) (string, bool) {
+	s := strings.TrimSpace(string(tokens.Bytes()))
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' || strings.Contains(s, "${") {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// copyModule copies the files of the module in dir to target, except for
+// hidden directories like .git and .terraform.
+func copyModule(dir, target string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(target, rel), 0755)
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Links to directories are not followed
+			if info, err := os.Stat(p); err == nil && info.IsDir() {
+				return nil
+			}
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(target, rel), data, 0644)
+	})
+}
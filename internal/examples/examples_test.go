@@ -0,0 +1,180 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/module"
+)
+
+var (
+	host = svchost.Hostname("registrytools.cloud")
+	mod  = module.Module{Namespace: "platform", Name: "vpc", System: "aws"}
+)
+
+func TestFind(t *testing.T) {
+	found, err := Find("./fixtures/module")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if strings.Join(found, ",") != "examples/basic,examples/submodule" {
+		t.Errorf("unexpected examples %v", found)
+	}
+
+	found, err = Find(t.TempDir())
+	if err != nil || len(found) != 0 {
+		t.Errorf("expected no examples, got %v, %v", found, err)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	src := `module "vpc" {
+  source  = "REGISTRYTOOLS.cloud/platform/VPC/aws"
+  version = "~> 1.0"
+}
+
+module "subnets" {
+  source = "registrytools.cloud/platform/vpc/aws//modules/subnets"
+}
+
+module "other" {
+  source  = "registrytools.cloud/platform/other/aws"
+  version = "~> 1.0"
+}
+
+module "local" {
+  source = "../../modules/local"
+}
+`
+	hostname, err := svchost.ForComparison("REGISTRYTOOLS.cloud")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, changed, err := Rewrite([]byte(src), "main.tf", "../..", hostname, mod)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !changed {
+		t.Fatal("expected the module calls to be rewritten")
+	}
+
+	expected := `module "vpc" {
+  source = "../.."
+}
+
+module "subnets" {
+  source = "../../modules/subnets"
+}
+
+module "other" {
+  source  = "registrytools.cloud/platform/other/aws"
+  version = "~> 1.0"
+}
+
+module "local" {
+  source = "../../modules/local"
+}
+`
+	if string(updated) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, updated)
+	}
+
+	_, changed, err = Rewrite([]byte(`module "x" { source = "./x" }`), "main.tf", "../..", host, mod)
+	if err != nil || changed {
+		t.Errorf("expected no change, got %t, %v", changed, err)
+	}
+}
+
+// fakeTerraform writes a script that records the directory and arguments of
+// each call to log, and fails "validate" in the submodule example.
+func fakeTerraform(t *testing.T, log string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake terraform binary is a shell script")
+	}
+
+	script := `#!/bin/sh
+echo "$(basename "$PWD") $*" >> "` + log + `"
+if [ "$1" = "validate" ] && [ "$(basename "$PWD")" = "submodule" ]; then
+  cat main.tf
+  exit 1
+fi
+`
+	path := filepath.Join(t.TempDir(), "terraform")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunnerTest(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "calls.log")
+	runner, err := NewRunner(fakeTerraform(t, log))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	results, err := runner.Test(context.Background(), "./fixtures/module", host, mod)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !results.Failed() {
+		t.Error("expected the submodule example to fail")
+	}
+
+	var summary []string
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = "failed"
+		}
+		summary = append(summary, result.Example+" "+string(result.Step)+" "+status)
+	}
+	expected := []string{
+		"examples/basic fmt ok",
+		"examples/basic init ok",
+		"examples/basic validate ok",
+		"examples/submodule fmt ok",
+		"examples/submodule init ok",
+		"examples/submodule validate failed",
+	}
+	if strings.Join(summary, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(summary, "\n"))
+	}
+
+	// The rewritten configuration is validated, not the original
+	if output := results[5].Output; !strings.Contains(output, `source = "../../modules/subnets"`) {
+		t.Errorf("expected the rewritten source in the output, got:\n%s", output)
+	}
+
+	calls, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(calls), "basic init -backend=false -input=false -no-color") {
+		t.Errorf("unexpected calls:\n%s", calls)
+	}
+
+	original, err := os.ReadFile("./fixtures/module/examples/basic/main.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(original), "registrytools.cloud/platform/vpc/aws") {
+		t.Error("expected the original example not to be modified")
+	}
+}
+
+func TestNewRunnerNotFound(t *testing.T) {
+	_, err := NewRunner(filepath.Join(t.TempDir(), "terraform"))
+	if !errors.Is(err, ErrTerraformNotFound) {
+		t.Errorf("expected ErrTerraformNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,6 @@
+module "vpc" {
+  source  = "registrytools.cloud/platform/vpc/aws"
+  version = "~> 1.0"
+
+  name = "basic"
+}
@@ -0,0 +1,7 @@
+module "subnets" {
+  source = "registrytools.cloud/platform/vpc/aws//modules/subnets"
+}
+
+module "other" {
+  source = "registrytools.cloud/platform/other/aws"
+}
@@ -0,0 +1,105 @@
+// Package gitsource exports module sources from git repositories.
+package gitsource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Export is the result of exporting a ref of a repository.
+type Export struct {
+	// Dir contains the files of the requested subdirectory at the ref. It is
+	// inside a temporary directory that is removed by Close.
+	Dir string
+	// Commit is the full SHA of the exported commit.
+	Commit string
+
+	root string
+}
+
+// Close removes the exported files.
+func (e *Export) Close() error {
+	return os.RemoveAll(e.root)
+}
+
+func git(ctx context.Context, args ...string) (string, error) {
+	log.Printf("[DEBUG] git %s", strings.Join(args, " "))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ExportRef clones repository, which may be a URL or the path of a local
+// repository (including bare repositories), and writes the files of subdir
+// at ref to a temporary directory. ref may be a branch, tag or commit, and
+// defaults to HEAD. The repository metadata is not included in the export.
+func ExportRef(ctx context.Context, repository, ref, subdir string) (*Export, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	subdir = path.Clean(filepath.ToSlash(subdir))
+	if subdir == ".." || strings.HasPrefix(subdir, "../") || path.IsAbs(subdir) {
+		return nil, fmt.Errorf("subdirectory %q must be within the repository", subdir)
+	}
+
+	root, err := os.MkdirTemp("", "rt-git")
+	if err != nil {
+		return nil, err
+	}
+
+	export := &Export{root: root}
+	ok := false
+	defer func() {
+		if !ok {
+			export.Close()
+		}
+	}()
+
+	gitDir := filepath.Join(root, "repo.git")
+	if _, err := git(ctx, "clone", "--quiet", "--bare", "--", repository, gitDir); err != nil {
+		return nil, err
+	}
+
+	export.Commit, err = git(ctx, "--git-dir", gitDir, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("ref %q was not found in %s", ref, repository)
+	}
+
+	workTree := filepath.Join(root, "src")
+	if err := os.Mkdir(workTree, 0755); err != nil {
+		return nil, err
+	}
+
+	// Checking out into a separate work tree leaves the git metadata behind
+	if _, err := git(ctx, "--git-dir", gitDir, "--work-tree", workTree, "checkout", "--quiet", export.Commit, "--", "."); err != nil {
+		return nil, err
+	}
+
+	export.Dir = filepath.Join(workTree, filepath.FromSlash(subdir))
+	info, err := os.Stat(export.Dir)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("subdirectory %q does not exist at %s", subdir, ref)
+	}
+
+	ok = true
+	return export, nil
+}
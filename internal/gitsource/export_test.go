@@ -0,0 +1,131 @@
+package gitsource
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newBareRepository creates a bare repository with two tagged commits.
+func newBareRepository(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	work := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = work
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(work, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "--quiet", "--initial-branch=main")
+	write("modules/vpc/main.tf", "# v1\n")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "v1")
+	run("tag", "v1.0.0")
+	write("modules/vpc/main.tf", "# v2\n")
+	run("commit", "--quiet", "-am", "v2")
+	run("tag", "v2.0.0")
+
+	bare := filepath.Join(t.TempDir(), "modules.git")
+	run("clone", "--quiet", "--bare", work, bare)
+	return bare
+}
+
+func TestExportRef(t *testing.T) {
+	repo := newBareRepository(t)
+	ctx := context.Background()
+
+	export, err := ExportRef(ctx, repo, "v1.0.0", "modules/vpc")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	defer export.Close()
+
+	content, err := os.ReadFile(filepath.Join(export.Dir, "main.tf"))
+	if err != nil || string(content) != "# v1\n" {
+		t.Errorf("expected v1 of main.tf, got %q (%v)", content, err)
+	}
+	if len(export.Commit) != 40 {
+		t.Errorf("expected a full commit SHA, got %q", export.Commit)
+	}
+	if _, err := os.Stat(filepath.Join(export.Dir, "..", "..", ".git")); err == nil {
+		t.Error("expected the export not to include git metadata")
+	}
+
+	latest, err := ExportRef(ctx, repo, "", ".")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	defer latest.Close()
+
+	content, _ = os.ReadFile(filepath.Join(latest.Dir, "modules", "vpc", "main.tf"))
+	if string(content) != "# v2\n" {
+		t.Errorf("expected HEAD to be exported, got %q", content)
+	}
+	if latest.Commit == export.Commit {
+		t.Error("expected different commits for v1.0.0 and HEAD")
+	}
+
+	if _, err := ExportRef(ctx, repo, "v9.9.9", "."); err == nil {
+		t.Error("expected an error for a missing ref")
+	}
+	if _, err := ExportRef(ctx, repo, "v1.0.0", "modules/missing"); err == nil {
+		t.Error("expected an error for a missing subdirectory")
+	}
+	if _, err := ExportRef(ctx, repo, "v1.0.0", "../outside"); err == nil {
+		t.Error("expected an error for a subdirectory outside the repository")
+	}
+
+	root := export.root
+	export.Close()
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Error("expected Close to remove the export")
+	}
+}
+
+func TestExportRefRepositoryLikeOption(t *testing.T) {
+	repo := newBareRepository(t)
+
+	// A repository path that starts with a hyphen must not be read as an
+	// option of git clone
+	dir := t.TempDir()
+	if err := os.Rename(repo, filepath.Join(dir, "-modules.git")); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	export, err := ExportRef(context.Background(), "-modules.git", "v1.0.0", ".")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	export.Close()
+}
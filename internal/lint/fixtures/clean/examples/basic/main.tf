@@ -0,0 +1,8 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+module "vpc" {
+  source = "../.."
+  cidr   = "10.0.0.0/16"
+}
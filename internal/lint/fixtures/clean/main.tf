@@ -0,0 +1,28 @@
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "cidr" {
+  description = "The CIDR block of the VPC."
+  type        = string
+}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+}
+
+resource "terraform_data" "marker" {
+  input = var.cidr
+}
+
+output "vpc_id" {
+  description = "The ID of the VPC."
+  value       = aws_vpc.this.id
+}
@@ -0,0 +1,43 @@
+terraform {
+  required_version = "< 2.0"
+
+  required_providers {
+    aws    = "~> 5.0"
+    random = {
+      source = "hashicorp/random"
+    }
+  }
+
+  backend "s3" {
+    bucket = "state"
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+variable "cidr" {
+  description = ""
+}
+
+# rt-lint-ignore: variable-description
+variable "name" {
+  type = string
+}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+}
+
+resource "google_compute_network" "this" {
+  name = var.name
+}
+
+output "vpc_id" { // rt-lint-ignore: output-description
+  value = aws_vpc.this.id
+}
+
+output "network" {
+  value = google_compute_network.this.id
+}
@@ -0,0 +1,317 @@
+// Package lint checks a module's configuration against rules for module
+// quality, like documenting every input and output and pinning the versions
+// of Terraform and providers.
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+
+	"github.com/registry-tools/rt-cli/internal/moduleconfig"
+	"github.com/registry-tools/rt-cli/internal/publish"
+)
+
+// Severity is how serious a finding is. Findings with SeverityError fail
+// "rt lint".
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNotice  Severity = "notice"
+	// SeverityOff disables a rule.
+	SeverityOff Severity = "off"
+)
+
+// Finding is a violation of a rule.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	// File is relative to the module directory, and is empty if the finding
+	// does not relate to a particular file.
+	File string
+	Line int
+}
+
+func (f Finding) String() string {
+	if f.File == "" {
+		return fmt.Sprintf("%s [%s]", f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%s:%d: %s [%s]", f.File, f.Line, f.Message, f.Rule)
+}
+
+// Findings is a list of findings, sorted by file and line.
+type Findings []Finding
+
+// HasErrors reports whether any of the findings has SeverityError.
+func (f Findings) HasErrors() bool {
+	for _, finding := range f {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Problems converts the findings to problems, so they can be reported like
+// the problems found by publish.Validate.
+func (f Findings) Problems() publish.Problems {
+	problems := make(publish.Problems, 0, len(f))
+	for _, finding := range f {
+		severity := hcl.DiagWarning
+		if finding.Severity == SeverityError {
+			severity = hcl.DiagError
+		}
+		problems = append(problems, publish.Problem{
+			Severity: severity,
+			Summary:  fmt.Sprintf("%s [%s]", finding.Message, finding.Rule),
+			File:     finding.File,
+			Line:     finding.Line,
+		})
+	}
+	return problems
+}
+
+// Rule is a check run against a module.
+type Rule struct {
+	ID          string
+	Description string
+	// Severity is the severity of the rule's findings unless it is
+	// configured in rt.yaml.
+	Severity Severity
+
+	check func(m *module) []Finding
+}
+
+// Rules returns every rule, in the order they are run.
+func Rules() []Rule {
+	return rules
+}
+
+// ignoreComment suppresses the listed rules on the line of the comment and
+// the line after it, Ex: "# rt-lint-ignore: backend".
+var ignoreComment = regexp.MustCompile(`(?:#|//)\s*rt-lint-ignore:\s*([a-z0-9-]+(?:\s*,\s*[a-z0-9-]+)*)`)
+
+// Lint runs every rule that is not turned off against the module in dir, and
+// returns the findings that are not suppressed by an ignore comment.
+func Lint(dir string, config moduleconfig.Lint) (Findings, error) {
+	severities, err := ruleSeverities(config)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings Findings
+	for _, rule := range rules {
+		severity := severities[rule.ID]
+		if severity == SeverityOff {
+			continue
+		}
+		for _, finding := range rule.check(m) {
+			if m.ignored(finding.File, finding.Line, rule.ID) {
+				continue
+			}
+			finding.Rule = rule.ID
+			finding.Severity = severity
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+func ruleSeverities(config moduleconfig.Lint) (map[string]Severity, error) {
+	severities := make(map[string]Severity, len(rules))
+	for _, rule := range rules {
+		severities[rule.ID] = rule.Severity
+	}
+
+	for id, value := range config.Rules {
+		if _, ok := severities[id]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", id)
+		}
+		switch severity := Severity(value); severity {
+		case SeverityError, SeverityWarning, SeverityNotice, SeverityOff:
+			severities[id] = severity
+		default:
+			return nil, fmt.Errorf("invalid severity %q for lint rule %q; expected error, warning, notice or off", value, id)
+		}
+	}
+	return severities, nil
+}
+
+// module is the parsed configuration of a module directory, grouped by the
+// directory each file is in. Examples are not included.
+type module struct {
+	dir     string
+	configs []*config
+	// ignores maps a file and line to the rules ignored on it.
+	ignores map[string]map[int][]string
+}
+
+// config is the configuration in one directory: the root module or a nested
+// module.
+type config struct {
+	// dir is relative to the module directory, using forward slashes.
+	dir   string
+	files []*file
+}
+
+type file struct {
+	// name is relative to the module directory, using forward slashes.
+	name    string
+	content *hcl.BodyContent
+}
+
+var rootSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "terraform"},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+		{Type: "provider", LabelNames: []string{"name"}},
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+	},
+}
+
+func load(dir string) (*module, error) {
+	m := &module{dir: dir, ignores: map[string]map[int][]string{}}
+	parser := hclparse.NewParser()
+	configs := map[string]*config{}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			// Examples are root modules of their own, checked by the
+			// examples-valid rule
+			if p == filepath.Join(dir, "examples") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		var f *hcl.File
+		var diags hcl.Diagnostics
+		switch {
+		case strings.HasSuffix(rel, ".tf"):
+			f, diags = parser.ParseHCLFile(p)
+		case strings.HasSuffix(rel, ".tf.json"):
+			f, diags = parser.ParseJSONFile(p)
+		default:
+			return nil
+		}
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to parse %s: %w", rel, errors.New(diags.Error()))
+		}
+
+		content, _, diags := f.Body.PartialContent(rootSchema)
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to parse %s: %w", rel, errors.New(diags.Error()))
+		}
+
+		configDir := path.Dir(rel)
+		c, ok := configs[configDir]
+		if !ok {
+			c = &config{dir: configDir}
+			configs[configDir] = c
+			m.configs = append(m.configs, c)
+		}
+		c.files = append(c.files, &file{name: rel, content: content})
+
+		if strings.HasSuffix(rel, ".tf") {
+			m.readIgnores(rel, f.Bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := configs["."]; !ok {
+		return nil, fmt.Errorf("no .tf or .tf.json files found in %s", dir)
+	}
+	return m, nil
+}
+
+func (m *module) readIgnores(name string, src []byte) {
+	for i, line := range strings.Split(string(src), "\n") {
+		match := ignoreComment.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if m.ignores[name] == nil {
+			m.ignores[name] = map[int][]string{}
+		}
+		for _, id := range strings.Split(match[1], ",") {
+			m.ignores[name][i+1] = append(m.ignores[name][i+1], strings.TrimSpace(id))
+		}
+	}
+}
+
+// ignored reports whether an ignore comment on the line of a finding, or the
+// line before it, suppresses the rule.
+func (m *module) ignored(name string, line int, rule string) bool {
+	for _, l := range []int{line, line - 1} {
+		for _, id := range m.ignores[name][l] {
+			if id == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blocks returns the blocks of a type in every file of the configuration.
+func (c *config) blocks(blockType string) []fileBlock {
+	var result []fileBlock
+	for _, f := range c.files {
+		for _, block := range f.content.Blocks {
+			if block.Type == blockType {
+				result = append(result, fileBlock{file: f.name, Block: block})
+			}
+		}
+	}
+	return result
+}
+
+type fileBlock struct {
+	*hcl.Block
+	file string
+}
+
+func (b fileBlock) finding(format string, args ...any) Finding {
+	return Finding{Message: fmt.Sprintf(format, args...), File: b.file, Line: b.DefRange.Start.Line}
+}
@@ -0,0 +1,149 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/registry-tools/rt-cli/internal/moduleconfig"
+)
+
+func TestLint(t *testing.T) {
+	findings, err := Lint("./fixtures/module", moduleconfig.Lint{
+		Rules: map[string]string{"backend": "warning", "output-description": "off"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := []string{
+		"examples/broken/main.tf:1 examples-valid error",
+		"main.tf:2 required-version error",
+		"main.tf:5 required-providers error",
+		"main.tf:6 required-providers error",
+		"main.tf:11 backend warning",
+		"main.tf:16 provider-configuration error",
+		"main.tf:20 variable-description warning",
+		"main.tf:20 variable-type warning",
+		"main.tf:33 required-providers error",
+		"modules/subnets/main.tf.json:1 required-version error",
+	}
+
+	var actual []string
+	for _, finding := range findings {
+		actual = append(actual, fmt.Sprintf("%s:%d %s %s", finding.File, finding.Line, finding.Rule, finding.Severity))
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected findings:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+
+	if !findings.HasErrors() {
+		t.Error("expected errors")
+	}
+	if problems := findings.Problems(); !problems.HasErrors() || len(problems) != len(findings) {
+		t.Errorf("expected %d problems with errors, got %v", len(findings), problems)
+	}
+}
+
+func TestLintIgnoreComments(t *testing.T) {
+	findings, err := Lint("./fixtures/module", moduleconfig.Lint{})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	for _, finding := range findings {
+		if strings.Contains(finding.Message, `"name"`) || strings.Contains(finding.Message, `"vpc_id"`) {
+			t.Errorf("expected finding to be ignored: %s", finding)
+		}
+	}
+	if !strings.Contains(fmt.Sprint(findings), `Output "network" has no description`) {
+		t.Errorf("expected a finding for the undocumented output, got %v", findings)
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	findings, err := Lint("./fixtures/clean", moduleconfig.Lint{})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestLintInvalidConfig(t *testing.T) {
+	cases := map[string]map[string]string{
+		"unknown rule":     {"no-such-rule": "error"},
+		"unknown severity": {"backend": "fatal"},
+	}
+	for name, rules := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Lint("./fixtures/clean", moduleconfig.Lint{Rules: rules}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLintEmptyDirectory(t *testing.T) {
+	if _, err := Lint(t.TempDir(), moduleconfig.Lint{}); err == nil {
+		t.Error("expected an error for a directory without configuration files")
+	}
+}
+
+func TestCheckLowerBound(t *testing.T) {
+	cases := map[string]bool{
+		">= 1.5":         true,
+		"~> 5.0":         true,
+		"1.2.3":          true,
+		">= 1.0, < 2.0":  true,
+		"< 2.0":          false,
+		"!= 1.1, < 2.0":  false,
+		"not a version!": false,
+	}
+	for constraint, ok := range cases {
+		if problem := checkLowerBound(constraint); (problem == "") != ok {
+			t.Errorf("%q: expected lower bound %t, got %q", constraint, ok, problem)
+		}
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	findings := Findings{
+		{Rule: "backend", Severity: SeverityError, Message: "Backend", File: "main.tf", Line: 3},
+		{Rule: "variable-type", Severity: SeverityNotice, Message: "Type"},
+	}
+
+	data, err := findings.SARIF("modules/vpc")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("expected valid JSON, got %s", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log %s", data)
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(rules) {
+		t.Errorf("expected %d rules, got %d", len(rules), len(run.Tool.Driver.Rules))
+	}
+
+	first := run.Results[0]
+	if first.Level != "error" || first.RuleID != "backend" || run.Tool.Driver.Rules[first.RuleIndex].ID != "backend" {
+		t.Errorf("unexpected result %+v", first)
+	}
+	location := first.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "modules/vpc/main.tf" || location.Region.StartLine != 3 {
+		t.Errorf("unexpected location %+v", location)
+	}
+
+	second := run.Results[1]
+	if second.Level != "note" || len(second.Locations) != 0 {
+		t.Errorf("unexpected result %+v", second)
+	}
+}
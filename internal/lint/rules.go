@@ -0,0 +1,333 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/registry-tools/rt-cli/internal/publish"
+)
+
+var rules = []Rule{
+	{
+		ID:          "variable-description",
+		Description: "Every variable has a description",
+		Severity:    SeverityWarning,
+		check:       checkAttribute("variable", "Variable", "description"),
+	},
+	{
+		ID:          "variable-type",
+		Description: "Every variable has a type",
+		Severity:    SeverityWarning,
+		check:       checkAttribute("variable", "Variable", "type"),
+	},
+	{
+		ID:          "output-description",
+		Description: "Every output has a description",
+		Severity:    SeverityWarning,
+		check:       checkAttribute("output", "Output", "description"),
+	},
+	{
+		ID:          "provider-configuration",
+		Description: "Modules do not configure providers; the calling module does",
+		Severity:    SeverityError,
+		check:       checkProviderConfiguration,
+	},
+	{
+		ID:          "required-version",
+		Description: "required_version sets a lower bound on the Terraform version",
+		Severity:    SeverityError,
+		check:       checkRequiredVersion,
+	},
+	{
+		ID:          "required-providers",
+		Description: "Every provider is in required_providers, with a source and a lower bound on its version",
+		Severity:    SeverityError,
+		check:       checkRequiredProviders,
+	},
+	{
+		ID:          "backend",
+		Description: "Modules do not configure a backend or HCP Terraform",
+		Severity:    SeverityError,
+		check:       checkBackend,
+	},
+	{
+		ID:          "examples-valid",
+		Description: "Every example in the examples directory can be parsed",
+		Severity:    SeverityError,
+		check:       checkExamples,
+	},
+}
+
+var (
+	terraformSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "required_version"}},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "required_providers"},
+			{Type: "backend", LabelNames: []string{"type"}},
+			{Type: "cloud"},
+		},
+	}
+	resourceSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "provider"}},
+	}
+)
+
+// checkAttribute returns a check that every block of blockType has a non-empty
+// attribute.
+func checkAttribute(blockType, label, attribute string) func(m *module) []Finding {
+	schema := &hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: attribute}}}
+
+	return func(m *module) []Finding {
+		var findings []Finding
+		for _, c := range m.configs {
+			for _, block := range c.blocks(blockType) {
+				content, _, _ := block.Body.PartialContent(schema)
+				attr := content.Attributes[attribute]
+				if attr == nil || isEmptyString(attr.Expr) {
+					findings = append(findings, block.finding("%s %q has no %s", label, block.Labels[0], attribute))
+				}
+			}
+		}
+		return findings
+	}
+}
+
+func checkProviderConfiguration(m *module) []Finding {
+	var findings []Finding
+	for _, c := range m.configs {
+		for _, block := range c.blocks("provider") {
+			findings = append(findings, block.finding("Provider %q is configured in the module. Declare it in required_providers, and configure it in the calling module instead", block.Labels[0]))
+		}
+	}
+	return findings
+}
+
+func checkRequiredVersion(m *module) []Finding {
+	var findings []Finding
+	for _, c := range m.configs {
+		found := false
+		for _, block := range c.blocks("terraform") {
+			content, _, _ := block.Body.PartialContent(terraformSchema)
+			attr := content.Attributes["required_version"]
+			if attr == nil {
+				continue
+			}
+			found = true
+
+			constraint, ok := stringValue(attr.Expr)
+			if !ok {
+				findings = append(findings, attributeFinding(block.file, attr, "required_version must be a string"))
+			} else if problem := checkLowerBound(constraint); problem != "" {
+				findings = append(findings, attributeFinding(block.file, attr, "required_version %q %s", constraint, problem))
+			}
+		}
+		if !found {
+			findings = append(findings, c.finding("%s does not set terraform { required_version }", c.describe()))
+		}
+	}
+	return findings
+}
+
+func checkRequiredProviders(m *module) []Finding {
+	var findings []Finding
+	for _, c := range m.configs {
+		declared := map[string]bool{}
+		for _, block := range c.blocks("terraform") {
+			content, _, _ := block.Body.PartialContent(terraformSchema)
+			for _, providers := range content.Blocks {
+				if providers.Type != "required_providers" {
+					continue
+				}
+				attrs, _ := providers.Body.JustAttributes()
+				for _, name := range sortedNames(attrs) {
+					declared[name] = true
+					findings = append(findings, checkRequiredProvider(block.file, attrs[name])...)
+				}
+			}
+		}
+
+		// Report each undeclared provider once, where it is first used
+		reported := map[string]bool{}
+		for _, blockType := range []string{"resource", "data"} {
+			for _, block := range c.blocks(blockType) {
+				name := resourceProvider(block)
+				if name == "terraform" || declared[name] || reported[name] {
+					continue
+				}
+				reported[name] = true
+				findings = append(findings, block.finding("Provider %q is used but not declared in required_providers", name))
+			}
+		}
+	}
+	return findings
+}
+
+func checkRequiredProvider(file string, attr *hcl.Attribute) []Finding {
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() {
+		return []Finding{attributeFinding(file, attr, "Provider %q must be an object with a source and version", attr.Name)}
+	}
+	if value.Type() == cty.String {
+		return []Finding{attributeFinding(file, attr, "Provider %q has no source. Use { source = \"...\", version = %q }", attr.Name, value.AsString())}
+	}
+	if !value.Type().IsObjectType() {
+		return []Finding{attributeFinding(file, attr, "Provider %q must be an object with a source and version", attr.Name)}
+	}
+
+	var findings []Finding
+	if source := objectString(value, "source"); source == "" {
+		findings = append(findings, attributeFinding(file, attr, "Provider %q has no source", attr.Name))
+	}
+	if constraint := objectString(value, "version"); constraint == "" {
+		findings = append(findings, attributeFinding(file, attr, "Provider %q has no version constraint", attr.Name))
+	} else if problem := checkLowerBound(constraint); problem != "" {
+		findings = append(findings, attributeFinding(file, attr, "Provider %q version %q %s", attr.Name, constraint, problem))
+	}
+	return findings
+}
+
+func checkBackend(m *module) []Finding {
+	var findings []Finding
+	for _, c := range m.configs {
+		for _, block := range c.blocks("terraform") {
+			content, _, _ := block.Body.PartialContent(terraformSchema)
+			for _, nested := range content.Blocks {
+				b := fileBlock{file: block.file, Block: nested}
+				switch nested.Type {
+				case "backend":
+					findings = append(findings, b.finding("Backend %q is configured in the module. Only root modules can configure a backend", nested.Labels[0]))
+				case "cloud":
+					findings = append(findings, b.finding("HCP Terraform is configured in the module. Only root modules can configure it"))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// checkExamples validates each directory in the examples directory as a
+// module of its own.
+func checkExamples(m *module) []Finding {
+	entries, err := os.ReadDir(filepath.Join(m.dir, "examples"))
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		example := path.Join("examples", entry.Name())
+		problems, err := publish.Validate(filepath.Join(m.dir, filepath.FromSlash(example)))
+		if err != nil {
+			findings = append(findings, Finding{Message: err.Error(), File: example})
+			continue
+		}
+
+		for _, problem := range problems {
+			if problem.Severity != hcl.DiagError {
+				continue
+			}
+			finding := Finding{Message: "Example " + entry.Name() + ": " + problem.Summary, Line: problem.Line}
+			if problem.Detail != "" {
+				finding.Message += "; " + problem.Detail
+			}
+			if problem.File != "" {
+				finding.File = path.Join(example, filepath.ToSlash(problem.File))
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// checkLowerBound describes what is wrong with a version constraint, or
+// returns an empty string if it has a lower bound.
+func checkLowerBound(constraint string) string {
+	if _, err := version.NewConstraint(constraint); err != nil {
+		return "is not a valid version constraint"
+	}
+
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "<") || strings.HasPrefix(part, "!=") {
+			continue
+		}
+		// >=, >, ~>, = and an exact version all set a lower bound
+		return ""
+	}
+	return "has no lower bound. Add one, Ex: \">= 1.0\""
+}
+
+// resourceProvider returns the local name of the provider of a resource: its
+// provider argument, or the prefix of its type.
+func resourceProvider(block fileBlock) string {
+	content, _, _ := block.Body.PartialContent(resourceSchema)
+	if attr := content.Attributes["provider"]; attr != nil {
+		if traversal, diags := hcl.AbsTraversalForExpr(attr.Expr); !diags.HasErrors() {
+			return traversal.RootName()
+		}
+	}
+	name, _, _ := strings.Cut(block.Labels[0], "_")
+	return name
+}
+
+func (c *config) describe() string {
+	if c.dir == "." {
+		return "The root module"
+	}
+	return "Module " + c.dir
+}
+
+// finding returns a finding on the first line of the configuration's first
+// file, for problems that aren't in any particular block.
+func (c *config) finding(format string, args ...any) Finding {
+	return Finding{Message: fmt.Sprintf(format, args...), File: c.files[0].name, Line: 1}
+}
+
+func attributeFinding(file string, attr *hcl.Attribute, format string, args ...any) Finding {
+	return Finding{Message: fmt.Sprintf(format, args...), File: file, Line: attr.NameRange.Start.Line}
+}
+
+func sortedNames(attrs hcl.Attributes) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isEmptyString(expr hcl.Expression) bool {
+	value, ok := stringValue(expr)
+	return ok && strings.TrimSpace(value) == ""
+}
+
+func stringValue(expr hcl.Expression) (string, bool) {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+func objectString(value cty.Value, name string) string {
+	if !value.Type().HasAttribute(name) {
+		return ""
+	}
+	attr := value.GetAttr(name)
+	if attr.IsNull() || !attr.IsKnown() || attr.Type() != cty.String {
+		return ""
+	}
+	return attr.AsString()
+}
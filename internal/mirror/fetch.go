@@ -0,0 +1,343 @@
+// Package mirror copies module versions from another registry.
+package mirror
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/registry-tools/rt-cli/internal/gitsource"
+	rtversion "github.com/registry-tools/rt-cli/version"
+)
+
+// Fetcher downloads module sources given by a registry's download endpoint.
+type Fetcher struct {
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Tokens are bearer tokens to send to hosts when downloading archives,
+	// keyed by hostname.
+	Tokens map[string]string
+}
+
+// splitSubdir separates the "//subdir" suffix of a source address, Ex:
+// "https://example.com/vpc.tar.gz//modules/subnets?archive=tar.gz".
+func splitSubdir(source string) (string, string) {
+	prefix := ""
+	if i := strings.Index(source, "::"); i >= 0 {
+		prefix, source = source[:i+2], source[i+2:]
+	}
+
+	schemeEnd := 0
+	if i := strings.Index(source, "://"); i >= 0 {
+		schemeEnd = i + 3
+	}
+
+	i := strings.Index(source[schemeEnd:], "//")
+	if i < 0 {
+		return prefix + source, ""
+	}
+
+	i += schemeEnd
+	subdir := source[i+2:]
+	query := ""
+	if j := strings.Index(subdir, "?"); j >= 0 {
+		subdir, query = subdir[:j], subdir[j:]
+	}
+
+	return prefix + source[:i] + query, subdir
+}
+
+// Fetch downloads the module source address into dest, and returns the
+// directory within dest that contains the module. Archives over http(s) and
+// "git::" addresses are supported.
+func (f Fetcher) Fetch(ctx context.Context, source, dest string) (string, error) {
+	address, subdir := splitSubdir(source)
+
+	var err error
+	switch {
+	case strings.HasPrefix(address, "git::"):
+		err = f.fetchGit(ctx, strings.TrimPrefix(address, "git::"), dest)
+	case strings.HasPrefix(address, "https://") || strings.HasPrefix(address, "http://"):
+		err = f.fetchArchive(ctx, address, dest)
+	default:
+		err = fmt.Errorf("unsupported module source %q: only http(s) archives and git:: sources can be mirrored", source)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	dir := dest
+	if subdir != "" {
+		dir, err = safeJoin(dest, subdir)
+		if err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+func (f Fetcher) fetchGit(ctx context.Context, address, dest string) error {
+	// The address comes from the source registry, so only repository URLs
+	// with known schemes are accepted, and nothing that git could read as an
+	// option
+	if strings.HasPrefix(address, "-") {
+		return fmt.Errorf("invalid git source %q", address)
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("invalid git source %q: %w", address, err)
+	}
+	switch u.Scheme {
+	case "https", "ssh", "file":
+	default:
+		return fmt.Errorf("unsupported git source %q: only https, ssh and file URLs can be mirrored", u.Redacted())
+	}
+
+	query := u.Query()
+	ref := query.Get("ref")
+	query.Del("ref")
+	u.RawQuery = query.Encode()
+
+	// The mirrored archive should match what Terraform would install, which
+	// does not include the repository metadata
+	export, err := gitsource.ExportRef(ctx, u.String(), ref, ".")
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", u.Redacted(), err)
+	}
+	defer export.Close()
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(export.Dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Rename(filepath.Join(export.Dir, entry.Name()), filepath.Join(dest, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveFormat returns the archive format of a URL, from the "archive" query
+// parameter used by Terraform or the file extension.
+func archiveFormat(u *url.URL) string {
+	if format := u.Query().Get("archive"); format != "" {
+		return format
+	}
+
+	name := path.Base(u.Path)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".tgz"):
+		return "tgz"
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// openArchive requests the archive at address and returns its body and
+// format. The caller must close the body.
+func (f Fetcher) openArchive(ctx context.Context, address string) (io.ReadCloser, string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid archive source %q: %w", address, err)
+	}
+
+	format := archiveFormat(u)
+	query := u.Query()
+	query.Del("archive")
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", "rt-cli/"+rtversion.Version)
+	if token := f.Tokens[u.Host]; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	httpClient := f.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	log.Printf("[DEBUG] GET %s", u.Redacted())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, "", fmt.Errorf("failed to download %s: %s", u.Redacted(), res.Status)
+	}
+
+	if format == "" {
+		res.Body.Close()
+		return nil, "", fmt.Errorf("unsupported archive %s: expected a .tar.gz, .tgz or .zip file", u.Redacted())
+	}
+
+	return res.Body, format, nil
+}
+
+func (f Fetcher) fetchArchive(ctx context.Context, address, dest string) error {
+	body, format, err := f.openArchive(ctx, address)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if format == "zip" {
+		return extractZip(body, dest)
+	}
+	return extractTarGz(body, dest)
+}
+
+// Download writes the archive at the source address to w as-is, without
+// extracting it. Only http(s) archives of a whole module are supported.
+func (f Fetcher) Download(ctx context.Context, source string, w io.Writer) error {
+	address, subdir := splitSubdir(source)
+	if subdir != "" || !(strings.HasPrefix(address, "https://") || strings.HasPrefix(address, "http://")) {
+		return fmt.Errorf("unsupported module source %q: only http(s) archives can be downloaded", source)
+	}
+
+	body, _, err := f.openArchive(ctx, address)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// safeJoin joins name to dir, refusing names that escape dir.
+func safeJoin(dir, name string) (string, error) {
+	joined := filepath.Join(dir, filepath.FromSlash(name))
+	if joined != dir && !strings.HasPrefix(joined, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal path %q in module source", name)
+	}
+	return joined, nil
+}
+
+func extractTarGz(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		target, err := safeJoin(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		default:
+			// Links and special files are not needed to use a module
+			log.Printf("[WARN] Skipping %q in module archive", header.Name)
+		}
+	}
+}
+
+func extractZip(r io.Reader, dest string) error {
+	// zip archives must be read with random access
+	tmp, err := os.CreateTemp("", "rt-mirror-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	for _, file := range zr.File {
+		target, err := safeJoin(dest, file.Name)
+		if err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			log.Printf("[WARN] Skipping %q in module archive", file.Name)
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(target, rc, file.Mode())
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,39 @@
+package mirror
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Missing returns the source versions allowed by constraint which are
+// not in existing, oldest first. Without a constraint, pre-releases are
+// included.
+func Missing(source version.Collection, existing []string, constraint string) (version.Collection, error) {
+	var constraints version.Constraints
+	if constraint != "" {
+		var err error
+		constraints, err = version.NewConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+	}
+
+	have := map[string]bool{}
+	for _, v := range existing {
+		if parsed, err := version.NewSemver(v); err == nil {
+			have[parsed.String()] = true
+		}
+	}
+
+	var missing version.Collection
+	for _, v := range source {
+		if constraints != nil && !constraints.Check(v) {
+			continue
+		}
+		if !have[v.String()] {
+			missing = append(missing, v)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,224 @@
+package mirror_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/registry-tools/rt-cli/internal/mirror"
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/publish"
+	"github.com/registry-tools/rt-cli/internal/registry"
+	"github.com/registry-tools/rt-cli/internal/server"
+	sdk "github.com/registry-tools/rt-sdk"
+)
+
+func TestMissing(t *testing.T) {
+	var source version.Collection
+	for _, v := range []string{"1.0.0", "1.1.0", "2.0.0-rc.1", "2.0.0"} {
+		source = append(source, version.Must(version.NewSemver(v)))
+	}
+
+	missing, err := mirror.Missing(source, []string{"1.0.0"}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(missing) != 3 || missing[0].String() != "1.1.0" {
+		t.Errorf("unexpected missing versions %v", missing)
+	}
+
+	missing, err = mirror.Missing(source, []string{"1.0.0"}, ">= 1.0, < 2.0")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(missing) != 1 || missing[0].String() != "1.1.0" {
+		t.Errorf("unexpected missing versions %v", missing)
+	}
+
+	if _, err := mirror.Missing(source, nil, "latest"); err == nil {
+		t.Error("expected an error for an invalid constraint")
+	}
+}
+
+// TestFetchFromRegistry fetches a module from a local registry standing in
+// for the source registry.
+func TestFetchFromRegistry(t *testing.T) {
+	srv := httptest.NewTLSServer(server.New(t.TempDir(), ""))
+	t.Cleanup(srv.Close)
+	serverURL, _ := url.Parse(srv.URL)
+
+	path, _, err := publish.PackAsFile("../publish/fixtures/moduleA", publish.PackOptions{})
+	if err != nil {
+		t.Fatalf("Failed to pack directory: %s", err)
+	}
+	defer os.Remove(path)
+	file, _ := os.Open(path)
+	defer file.Close()
+
+	client, _ := sdk.NewInsecureSDKForTesting(serverURL.Host)
+	if _, err := (publish.Publisher{SDK: client}).Publish(context.Background(), module.Module{Namespace: "hashicorp", Name: "consul", System: "aws", Version: "0.1.0"}, file); err != nil {
+		t.Fatalf("Failed to publish module: %s", err)
+	}
+
+	ctx := context.Background()
+	source := &registry.Client{BaseURL: serverURL.JoinPath("v1", "modules"), HTTPClient: srv.Client()}
+
+	versions, err := source.ModuleVersions(ctx, "hashicorp", "consul", "aws")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+
+	location, err := source.DownloadSource(ctx, "hashicorp", "consul", "aws", versions[0].Original())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	fetcher := mirror.Fetcher{HTTPClient: srv.Client()}
+	dir, err := fetcher.Fetch(ctx, location, t.TempDir())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected, _ := os.ReadFile("../publish/fixtures/moduleA/main.tf")
+	actual, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	if err != nil {
+		t.Fatalf("expected main.tf to be fetched: %s", err)
+	}
+	if string(actual) != string(expected) {
+		t.Errorf("unexpected main.tf contents %q", actual)
+	}
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		_, _ = tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestFetchArchive(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/module", func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer archive-token" {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = res.Write(tarGz(t, map[string]string{"main.tf": "# root", "modules/subnets/main.tf": "# subnets"}))
+	})
+	mux.HandleFunc("/escape.tar.gz", func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write(tarGz(t, map[string]string{"../escaped.tf": "# bad"}))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	serverURL, _ := url.Parse(srv.URL)
+
+	fetcher := mirror.Fetcher{Tokens: map[string]string{serverURL.Host: "archive-token"}}
+	ctx := context.Background()
+
+	dir, err := fetcher.Fetch(ctx, srv.URL+"/module//modules/subnets?archive=tar.gz", t.TempDir())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if actual, _ := os.ReadFile(filepath.Join(dir, "main.tf")); string(actual) != "# subnets" {
+		t.Errorf("expected the subdirectory to be returned, got %q", actual)
+	}
+
+	if _, err := fetcher.Fetch(ctx, srv.URL+"/escape.tar.gz", t.TempDir()); err == nil || !strings.Contains(err.Error(), "illegal path") {
+		t.Errorf("expected an illegal path error, got %v", err)
+	}
+
+	if _, err := fetcher.Fetch(ctx, "s3::https://example.com/module.zip", t.TempDir()); err == nil || !strings.Contains(err.Error(), "unsupported module source") {
+		t.Errorf("expected an unsupported source error, got %v", err)
+	}
+}
+
+func TestFetchGitCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "--quiet", "--initial-branch=main")
+	_ = os.WriteFile(filepath.Join(repo, "main.tf"), []byte("# v1\n"), 0644)
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	first := git("rev-parse", "HEAD")
+	_ = os.WriteFile(filepath.Join(repo, "main.tf"), []byte("# v2\n"), 0644)
+	git("commit", "--quiet", "-am", "v2")
+
+	ctx := context.Background()
+	for ref, expected := range map[string]string{"": "# v2\n", "main": "# v2\n", first: "# v1\n", first[:12]: "# v1\n"} {
+		address := "git::file://" + filepath.ToSlash(repo)
+		if ref != "" {
+			address += "?ref=" + ref
+		}
+
+		dir, err := mirror.Fetcher{}.Fetch(ctx, address, t.TempDir())
+		if err != nil {
+			t.Fatalf("expected no error for ref %q, got %s", ref, err)
+		}
+		if actual, _ := os.ReadFile(filepath.Join(dir, "main.tf")); string(actual) != expected {
+			t.Errorf("expected %q at ref %q, got %q", expected, ref, actual)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			t.Errorf("expected the git metadata to be left out at ref %q", ref)
+		}
+	}
+
+	if _, err := (mirror.Fetcher{}).Fetch(ctx, "git::file://"+filepath.ToSlash(repo)+"?ref=missing", t.TempDir()); err == nil {
+		t.Error("expected an error for a missing ref")
+	}
+}
+
+func TestFetchGitRejectsAddresses(t *testing.T) {
+	for _, address := range []string{
+		"git::--upload-pack=touch /tmp/injected",
+		"git::--config=core.sshCommand=touch /tmp/injected",
+		"git::http://example.com/vpc.git",
+		"git::ext::sh -c touch% /tmp/injected",
+		"git::/srv/git/vpc.git",
+	} {
+		if _, err := (mirror.Fetcher{}).Fetch(context.Background(), address, t.TempDir()); err == nil {
+			t.Errorf("expected an error for %q", address)
+		}
+	}
+}
@@ -29,3 +29,23 @@ func (m Module) ToTerraformExample(hostname svchost.Hostname) string {
 func (m Module) Source(hostname svchost.Hostname) string {
 	return strings.Join([]string{hostname.String(), m.Namespace, m.Name, m.System}, "/")
 }
+
+// ParseSource parses a registry module source address, Ex:
+// "registrytools.cloud/platform/vpc/aws", into the registry hostname and the
+// module, which has no version. A subdirectory of the module ("//path") is
+// ignored.
+func ParseSource(source string) (svchost.Hostname, Module, error) {
+	address, _, _ := strings.Cut(source, "//")
+
+	parts := strings.Split(strings.Trim(address, "/"), "/")
+	if len(parts) != 4 {
+		return "", Module{}, fmt.Errorf("invalid module source %q: expected <hostname>/<namespace>/<name>/<system>", source)
+	}
+
+	host, err := svchost.ForComparison(parts[0])
+	if err != nil {
+		return "", Module{}, fmt.Errorf("invalid module source %q: %w", source, err)
+	}
+
+	return host, Module{Namespace: parts[1], Name: parts[2], System: parts[3]}, nil
+}
@@ -0,0 +1,26 @@
+package module
+
+import "testing"
+
+func TestParseSource(t *testing.T) {
+	host, mod, err := ParseSource("RegistryTools.Cloud/platform/vpc/aws//modules/subnets")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if host.String() != "registrytools.cloud" {
+		t.Errorf("expected a normalized hostname, got %q", host)
+	}
+	if mod != (Module{Namespace: "platform", Name: "vpc", System: "aws"}) {
+		t.Errorf("unexpected module %+v", mod)
+	}
+	if mod.Source(host) != "registrytools.cloud/platform/vpc/aws" {
+		t.Errorf("unexpected source %q", mod.Source(host))
+	}
+
+	for _, source := range []string{"platform/vpc/aws", "./modules/vpc", "registrytools.cloud/platform/vpc"} {
+		if _, _, err := ParseSource(source); err == nil {
+			t.Errorf("expected an error for %q", source)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package moduleconfig reads rt.yaml, the optional configuration file kept in
+// the root of a module's directory.
+package moduleconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file in a module's directory.
+const FileName = "rt.yaml"
+
+// Config is the configuration of a module.
+type Config struct {
+	// Path is the file the configuration was read from, and is empty if the
+	// module does not have a configuration file.
+	Path string `yaml:"-"`
+
+	Lint Lint `yaml:"lint"`
+	Pack Pack `yaml:"pack"`
+}
+
+// Lint configures "rt lint".
+type Lint struct {
+	// Rules maps rule IDs to a severity, overriding the rule's default.
+	// Ex: "backend: off".
+	Rules map[string]string `yaml:"rules"`
+}
+
+// Pack configures which files are packed into the module's archive, in
+// addition to its .terraformignore file.
+type Pack struct {
+	// Include and Exclude are patterns in .terraformignore syntax. If Include
+	// is not empty, only matching paths are packed.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// GitIgnore also excludes the paths ignored by .gitignore files.
+	GitIgnore bool `yaml:"gitignore"`
+	// PreserveSymlinks refuses to pack symlinks that point outside of the
+	// module directory, instead of packing a copy of their target.
+	PreserveSymlinks bool `yaml:"preserve_symlinks"`
+}
+
+// Load reads the configuration file in dir. A missing file is not an error;
+// the zero configuration is returned instead.
+func Load(dir string) (*Config, error) {
+	path := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{Path: path}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return config, nil
+}
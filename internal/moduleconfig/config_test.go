@@ -0,0 +1,50 @@
+package moduleconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	content := "lint:\n  rules:\n    backend: off\n    variable-type: warning\n" +
+		"pack:\n  exclude: [\"*.md\", test/]\n  gitignore: true\n"
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Load(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if config.Path != filepath.Join(dir, FileName) {
+		t.Errorf("unexpected path %q", config.Path)
+	}
+	if config.Lint.Rules["backend"] != "off" || config.Lint.Rules["variable-type"] != "warning" {
+		t.Errorf("unexpected rules %v", config.Lint.Rules)
+	}
+	if len(config.Pack.Exclude) != 2 || config.Pack.Exclude[1] != "test/" || !config.Pack.GitIgnore || config.Pack.PreserveSymlinks {
+		t.Errorf("unexpected pack configuration %+v", config.Pack)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	config, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if config.Path != "" || config.Lint.Rules != nil {
+		t.Errorf("expected an empty configuration, got %+v", config)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("lint: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
@@ -0,0 +1,96 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cli/oauth/api"
+	"github.com/sethvargo/go-githubactions"
+)
+
+const (
+	// GrantTypeTokenExchange is the OAuth 2.0 token exchange grant (RFC 8693).
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	// TokenTypeIDToken identifies an OIDC ID token as the subject token.
+	TokenTypeIDToken = "urn:ietf:params:oauth:token-type:id_token"
+)
+
+// ErrNotAvailable is returned when the workflow was not granted permission to
+// request an OIDC token.
+var ErrNotAvailable = errors.New("an OIDC token is not available. Add the `id-token: write` permission to the workflow")
+
+// Available reports whether the GitHub Actions runtime can issue OIDC tokens
+// to the current job.
+func Available(action *githubactions.Action) bool {
+	return action.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL") != "" && action.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN") != ""
+}
+
+// Exchanger trades a GitHub Actions OIDC token for a short-lived registry token
+// that is bound to the workflow's repository.
+type Exchanger struct {
+	Action *githubactions.Action
+
+	// TokenURL is the registry's token endpoint.
+	TokenURL string
+	// Audience is the audience requested for the OIDC token. The registry
+	// rejects tokens that were not minted for it.
+	Audience string
+	// Scopes are requested for the registry token. Defaults to "publish".
+	Scopes []string
+
+	// HTTPClient is used for the exchange request. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Exchange requests an OIDC token from the GitHub Actions runtime and exchanges
+// it for a registry access token. The returned token is masked in the log.
+func (e Exchanger) Exchange(ctx context.Context) (string, error) {
+	if !Available(e.Action) {
+		return "", ErrNotAvailable
+	}
+
+	idToken, err := e.Action.GetIDToken(ctx, e.Audience)
+	if err != nil {
+		return "", fmt.Errorf("failed to request OIDC token from GitHub: %w", err)
+	}
+
+	scopes := e.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"publish"}
+	}
+
+	httpClient := e.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	log.Printf("[DEBUG] Exchanging GitHub OIDC token at %s", e.TokenURL)
+
+	params := url.Values{
+		"grant_type":         {GrantTypeTokenExchange},
+		"client_id":          {"rt-cli"},
+		"subject_token":      {idToken},
+		"subject_token_type": {TokenTypeIDToken},
+		"scope":              {strings.Join(scopes, " ")},
+	}
+
+	resp, err := api.PostForm(httpClient, e.TokenURL, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange OIDC token: %w", err)
+	}
+
+	token, err := resp.AccessToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange OIDC token: %w", err)
+	}
+
+	e.Action.AddMask(token.Token)
+
+	return token.Token, nil
+}
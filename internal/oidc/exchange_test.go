@@ -0,0 +1,103 @@
+package oidc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sethvargo/go-githubactions"
+
+	"github.com/registry-tools/rt-cli/internal/oidc"
+)
+
+// newTestServer plays both the GitHub Actions OIDC provider and the registry
+// token endpoint.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /github/token", func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer request-token" {
+			t.Errorf("expected the runtime request token, got %q", req.Header.Get("Authorization"))
+		}
+		if req.URL.Query().Get("audience") != "registry.example.com" {
+			t.Errorf("expected audience registry.example.com, got %q", req.URL.Query().Get("audience"))
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(`{"value":"github-id-token"}`))
+	})
+
+	mux.HandleFunc("POST /auth/token", func(res http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %s", err)
+		}
+
+		expected := map[string]string{
+			"grant_type":         oidc.GrantTypeTokenExchange,
+			"subject_token":      "github-id-token",
+			"subject_token_type": oidc.TokenTypeIDToken,
+			"scope":              "publish",
+		}
+		for k, v := range expected {
+			if req.PostForm.Get(k) != v {
+				t.Errorf("expected %s to be %q, got %q", k, v, req.PostForm.Get(k))
+			}
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		_, _ = res.Write([]byte(`{"access_token":"registry-token","token_type":"bearer","expires_in":900}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExchange(t *testing.T) {
+	server := newTestServer(t)
+
+	env := map[string]string{
+		"ACTIONS_ID_TOKEN_REQUEST_URL":   server.URL + "/github/token",
+		"ACTIONS_ID_TOKEN_REQUEST_TOKEN": "request-token",
+	}
+
+	var output bytes.Buffer
+	action := githubactions.New(
+		githubactions.WithGetenv(func(k string) string { return env[k] }),
+		githubactions.WithWriter(&output),
+	)
+
+	exchanger := oidc.Exchanger{
+		Action:   action,
+		TokenURL: server.URL + "/auth/token",
+		Audience: "registry.example.com",
+	}
+
+	token, err := exchanger.Exchange(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if token != "registry-token" {
+		t.Errorf("expected registry-token, got %q", token)
+	}
+
+	if !strings.Contains(output.String(), "::add-mask::registry-token") {
+		t.Errorf("expected the token to be masked, got output %q", output.String())
+	}
+}
+
+func TestExchangeNotAvailable(t *testing.T) {
+	action := githubactions.New(githubactions.WithGetenv(func(string) string { return "" }))
+
+	_, err := oidc.Exchanger{Action: action}.Exchange(context.Background())
+	if !errors.Is(err, oidc.ErrNotAvailable) {
+		t.Errorf("expected ErrNotAvailable, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Package preview derives the pre-release versions used to publish previews
+// of a module from pull requests.
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// shortSHALength is the number of commit SHA characters included in a
+// preview version.
+const shortSHALength = 7
+
+// PullRequestNumber returns the number of the pull request from a ref such as
+// "refs/pull/123/merge".
+func PullRequestNumber(ref string) (int, error) {
+	rest, ok := strings.CutPrefix(ref, "refs/pull/")
+	if !ok {
+		return 0, fmt.Errorf("%q is not a pull request ref", ref)
+	}
+
+	number, _, _ := strings.Cut(rest, "/")
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a pull request ref", ref)
+	}
+
+	return n, nil
+}
+
+// Version returns the pre-release version that previews base for pull
+// request number at commit sha, Ex: "1.4.0-pr.123.abcdef1".
+func Version(base string, number int, sha string) (string, error) {
+	v, err := version.NewSemver(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q: %w", base, err)
+	}
+
+	if v.Prerelease() != "" || v.Metadata() != "" {
+		return "", fmt.Errorf("version %q must not include a pre-release or build metadata to be used for previews", base)
+	}
+
+	if len(sha) < shortSHALength {
+		return "", errors.New("a commit SHA is required for preview versions")
+	}
+
+	return fmt.Sprintf("%s-%s%s", v.Core().String(), prereleasePrefix(number), shortSHA(sha)), nil
+}
+
+// shortSHA returns the abbreviated commit SHA used as the last pre-release
+// identifier. Semver forbids leading zeros in numeric identifiers, so an
+// abbreviation made only of digits is prefixed with "g", like git describe.
+func shortSHA(sha string) string {
+	short := strings.ToLower(sha[:shortSHALength])
+	if strings.Trim(short, "0123456789") == "" {
+		return "g" + short
+	}
+	return short
+}
+
+// IsPreview reports whether v is a preview version published for pull request
+// number.
+func IsPreview(v string, number int) bool {
+	parsed, err := version.NewSemver(v)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(parsed.Prerelease(), prereleasePrefix(number))
+}
+
+func prereleasePrefix(number int) string {
+	return fmt.Sprintf("pr.%d.", number)
+}
@@ -0,0 +1,70 @@
+package preview
+
+import "testing"
+
+func TestPullRequestNumber(t *testing.T) {
+	items := map[string]int{
+		"refs/pull/123/merge": 123,
+		"refs/pull/7/head":    7,
+		"refs/heads/main":     0,
+		"refs/pull/abc/merge": 0,
+	}
+
+	for ref, expected := range items {
+		actual, err := PullRequestNumber(ref)
+		if expected == 0 {
+			if err == nil {
+				t.Errorf("expected an error for %q", ref)
+			}
+			continue
+		}
+		if err != nil || actual != expected {
+			t.Errorf("expected %q to be %d, got %d (%v)", ref, expected, actual, err)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	actual, err := Version("1.4.0", 123, "ABCDEF1234567890")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if actual != "1.4.0-pr.123.abcdef1" {
+		t.Errorf("unexpected preview version %q", actual)
+	}
+
+	actual, err = Version("1.4.0", 123, "0123456abcdef")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if actual != "1.4.0-pr.123.g0123456" {
+		t.Errorf("unexpected preview version %q", actual)
+	}
+	if !IsPreview(actual, 123) {
+		t.Errorf("expected %q to be a valid preview version", actual)
+	}
+
+	if _, err := Version("1.4.0-beta.1", 123, "abcdef1"); err == nil {
+		t.Error("expected an error for a pre-release base version")
+	}
+	if _, err := Version("1.4.0", 123, ""); err == nil {
+		t.Error("expected an error without a commit SHA")
+	}
+}
+
+func TestIsPreview(t *testing.T) {
+	items := map[string]bool{
+		"1.4.0-pr.123.abcdef1":  true,
+		"1.3.0-pr.123.1234567":  true,
+		"1.4.0-pr.1234.abcdef1": false,
+		"1.4.0-pr.12.abcdef1":   false,
+		"1.4.0":                 false,
+		"1.4.0-beta.1":          false,
+	}
+
+	for v, expected := range items {
+		if actual := IsPreview(v, 123); actual != expected {
+			t.Errorf("expected IsPreview(%q) to be %v", v, expected)
+		}
+	}
+}
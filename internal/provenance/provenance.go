@@ -0,0 +1,179 @@
+// Package provenance builds in-toto statements with SLSA provenance, which
+// record how a module version was built and published.
+package provenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/version"
+)
+
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://slsa.dev/provenance/v1"
+
+	// BuildType describes how to interpret the build definition: the
+	// external parameters are the arguments of "rt publish".
+	BuildType = "https://github.com/registry-tools/rt-cli/publish/v1"
+
+	// DefaultBuilderID identifies builds that did not run in a known CI
+	// system, Ex: running "rt publish" on a workstation.
+	DefaultBuilderID = "https://github.com/registry-tools/rt-cli"
+
+	// AttachmentName and MediaType describe the statement when it is
+	// attached to a module version.
+	AttachmentName = "provenance.intoto.json"
+	MediaType      = "application/vnd.in-toto+json"
+)
+
+// Statement is an in-toto attestation statement.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is an artifact that the statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate is a SLSA v1 provenance predicate.
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]string    `json:"externalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+type ResourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+type RunDetails struct {
+	Builder  Builder        `json:"builder"`
+	Metadata *BuildMetadata `json:"metadata,omitempty"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type BuildMetadata struct {
+	InvocationID string     `json:"invocationId,omitempty"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+	FinishedOn   *time.Time `json:"finishedOn,omitempty"`
+}
+
+// Build describes how a module version was built.
+type Build struct {
+	Host   svchost.Hostname
+	Module module.Module
+
+	// ArchiveSHA256 is the hex encoded digest of the published archive.
+	ArchiveSHA256 string
+
+	// Parameters are the arguments that the module was published with.
+	Parameters map[string]string
+
+	// RepositoryURL, Commit and Ref identify the source, if it is known.
+	RepositoryURL string
+	Commit        string
+	Ref           string
+
+	// Workflow is the workflow or pipeline definition that ran the build.
+	Workflow string
+
+	// BuilderID and InvocationID identify the CI system and the run. The
+	// BuilderID defaults to DefaultBuilderID.
+	BuilderID    string
+	InvocationID string
+
+	StartedOn  time.Time
+	FinishedOn time.Time
+}
+
+// New returns the provenance statement for the build.
+func New(b Build) Statement {
+	parameters := map[string]string{}
+	for k, v := range b.Parameters {
+		if v != "" {
+			parameters[k] = v
+		}
+	}
+	if b.Workflow != "" {
+		parameters["workflow"] = b.Workflow
+	}
+
+	var dependencies []ResourceDescriptor
+	if b.RepositoryURL != "" {
+		dependency := ResourceDescriptor{URI: "git+" + b.RepositoryURL}
+		if b.Ref != "" {
+			dependency.URI += "@" + b.Ref
+		}
+		if b.Commit != "" {
+			dependency.Digest = map[string]string{"gitCommit": b.Commit}
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	builderID := b.BuilderID
+	if builderID == "" {
+		builderID = DefaultBuilderID
+	}
+
+	metadata := &BuildMetadata{InvocationID: b.InvocationID}
+	if !b.StartedOn.IsZero() {
+		started := b.StartedOn.UTC()
+		metadata.StartedOn = &started
+	}
+	if !b.FinishedOn.IsZero() {
+		finished := b.FinishedOn.UTC()
+		metadata.FinishedOn = &finished
+	}
+
+	return Statement{
+		Type: StatementType,
+		Subject: []Subject{{
+			Name:   fmt.Sprintf("%s@%s", b.Module.Source(b.Host), b.Module.Version),
+			Digest: map[string]string{"sha256": b.ArchiveSHA256},
+		}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType:            BuildType,
+				ExternalParameters:   parameters,
+				ResolvedDependencies: dependencies,
+			},
+			RunDetails: RunDetails{
+				Builder: Builder{
+					ID:      builderID,
+					Version: map[string]string{"rt-cli": version.Version},
+				},
+				Metadata: metadata,
+			},
+		},
+	}
+}
+
+// JSON returns the statement as indented JSON.
+func (s Statement) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode provenance: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,82 @@
+package provenance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/module"
+)
+
+func TestNew(t *testing.T) {
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	statement := New(Build{
+		Host:          svchost.Hostname("registrytools.cloud"),
+		Module:        module.Module{Namespace: "platform", Name: "vpc", System: "aws", Version: "1.2.3"},
+		ArchiveSHA256: "abc123",
+		Parameters:    map[string]string{"namespace": "platform", "version": "1.2.3", "directory": ""},
+		RepositoryURL: "https://github.com/org/terraform-aws-vpc",
+		Commit:        "0123456789abcdef",
+		Ref:           "refs/tags/v1.2.3",
+		Workflow:      "org/terraform-aws-vpc/.github/workflows/release.yml@refs/tags/v1.2.3",
+		BuilderID:     "https://github.com/org/terraform-aws-vpc/.github/workflows/release.yml@refs/tags/v1.2.3",
+		InvocationID:  "https://github.com/org/terraform-aws-vpc/actions/runs/42/attempts/1",
+		StartedOn:     started,
+	})
+
+	data, err := statement.JSON()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %s", err)
+	}
+	if decoded["_type"] != StatementType || decoded["predicateType"] != PredicateType {
+		t.Errorf("unexpected statement types in %s", data)
+	}
+
+	subject := statement.Subject[0]
+	if subject.Name != "registrytools.cloud/platform/vpc/aws@1.2.3" || subject.Digest["sha256"] != "abc123" {
+		t.Errorf("unexpected subject %+v", subject)
+	}
+
+	parameters := statement.Predicate.BuildDefinition.ExternalParameters
+	if _, ok := parameters["directory"]; ok {
+		t.Errorf("expected empty parameters to be omitted, got %v", parameters)
+	}
+	if parameters["workflow"] == "" || parameters["namespace"] != "platform" {
+		t.Errorf("unexpected parameters %v", parameters)
+	}
+
+	dependencies := statement.Predicate.BuildDefinition.ResolvedDependencies
+	if len(dependencies) != 1 || dependencies[0].URI != "git+https://github.com/org/terraform-aws-vpc@refs/tags/v1.2.3" || dependencies[0].Digest["gitCommit"] != "0123456789abcdef" {
+		t.Errorf("unexpected dependencies %+v", dependencies)
+	}
+
+	run := statement.Predicate.RunDetails
+	if run.Builder.ID != "https://github.com/org/terraform-aws-vpc/.github/workflows/release.yml@refs/tags/v1.2.3" {
+		t.Errorf("unexpected builder %q", run.Builder.ID)
+	}
+	if run.Metadata.StartedOn == nil || !run.Metadata.StartedOn.Equal(started) || run.Metadata.FinishedOn != nil {
+		t.Errorf("unexpected metadata %+v", run.Metadata)
+	}
+}
+
+func TestNewDefaultBuilder(t *testing.T) {
+	statement := New(Build{
+		Host:   svchost.Hostname("registrytools.cloud"),
+		Module: module.Module{Namespace: "platform", Name: "vpc", System: "aws", Version: "1.2.3"},
+	})
+
+	if statement.Predicate.RunDetails.Builder.ID != DefaultBuilderID {
+		t.Errorf("expected the default builder, got %q", statement.Predicate.RunDetails.Builder.ID)
+	}
+	if len(statement.Predicate.BuildDefinition.ResolvedDependencies) != 0 {
+		t.Errorf("expected no dependencies without a repository, got %+v", statement.Predicate.BuildDefinition.ResolvedDependencies)
+	}
+}
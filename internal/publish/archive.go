@@ -0,0 +1,92 @@
+package publish
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ArchiveInfo describes a module archive.
+type ArchiveInfo struct {
+	// Size is the total size of the files in the archive, uncompressed.
+	Size int64
+	// Files is the number of regular files in the archive.
+	Files int
+}
+
+// safeArchivePath reports whether name stays within the directory an archive
+// is extracted to.
+func safeArchivePath(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// ValidateArchive checks that the file at archivePath is a gzipped tarball
+// that can be published as a module: every path must stay within the
+// archive, links must point within the archive, and it must contain at least
+// one Terraform configuration file.
+func ValidateArchive(archivePath string) (*ArchiveInfo, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a gzip file: %w", archivePath, err)
+	}
+	defer gz.Close()
+
+	info := &ArchiveInfo{}
+	configFiles := 0
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid tar archive: %w", archivePath, err)
+		}
+
+		if !safeArchivePath(header.Name) {
+			return nil, fmt.Errorf("archive entry %q is outside of the module directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			info.Files++
+			info.Size += header.Size
+			if strings.HasSuffix(header.Name, ".tf") || strings.HasSuffix(header.Name, ".tf.json") {
+				configFiles++
+			}
+		case tar.TypeDir:
+		case tar.TypeSymlink, tar.TypeLink:
+			target := header.Linkname
+			if header.Typeflag == tar.TypeSymlink {
+				target = path.Join(path.Dir(header.Name), header.Linkname)
+			}
+			if path.IsAbs(header.Linkname) || !safeArchivePath(target) {
+				return nil, fmt.Errorf("archive entry %q links to %q, outside of the module directory", header.Name, header.Linkname)
+			}
+		default:
+			return nil, fmt.Errorf("archive entry %q is not a regular file, directory or link", header.Name)
+		}
+	}
+
+	if configFiles == 0 {
+		return nil, fmt.Errorf("%s does not contain any .tf or .tf.json files", archivePath)
+	}
+
+	return info, nil
+}
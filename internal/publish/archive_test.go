@@ -0,0 +1,95 @@
+package publish
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTarGz(t *testing.T, headers []tar.Header) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "module.tar.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, header := range headers {
+		header.Mode = 0644
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Size > 0 {
+			_, _ = tw.Write([]byte(strings.Repeat("#", int(header.Size))))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return path
+}
+
+func TestValidateArchive(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "module.tar.gz")
+	if _, err := PackToFile("./fixtures/moduleA", output, PackOptions{}); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	info, err := ValidateArchive(output)
+	if err != nil {
+		t.Fatalf("expected a packed module to be valid, got %s", err)
+	}
+	if info.Files == 0 || info.Size == 0 {
+		t.Errorf("unexpected archive info %+v", info)
+	}
+}
+
+func TestValidateArchiveErrors(t *testing.T) {
+	notGzip := filepath.Join(t.TempDir(), "module.tar.gz")
+	_ = os.WriteFile(notGzip, []byte("not an archive"), 0644)
+
+	cases := map[string]struct {
+		path     string
+		expected string
+	}{
+		"not gzip": {notGzip, "not a gzip file"},
+		"traversal": {writeTarGz(t, []tar.Header{
+			{Name: "main.tf", Size: 1, Typeflag: tar.TypeReg},
+			{Name: "../escape.tf", Size: 1, Typeflag: tar.TypeReg},
+		}), "outside of the module directory"},
+		"absolute": {writeTarGz(t, []tar.Header{
+			{Name: "/etc/main.tf", Size: 1, Typeflag: tar.TypeReg},
+		}), "outside of the module directory"},
+		"symlink": {writeTarGz(t, []tar.Header{
+			{Name: "main.tf", Size: 1, Typeflag: tar.TypeReg},
+			{Name: "modules/link", Linkname: "../../etc", Typeflag: tar.TypeSymlink},
+		}), "links to"},
+		"no config": {writeTarGz(t, []tar.Header{
+			{Name: "README.md", Size: 1, Typeflag: tar.TypeReg},
+		}), "does not contain any .tf"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ValidateArchive(c.path)
+			if err == nil || !strings.Contains(err.Error(), c.expected) {
+				t.Errorf("expected an error containing %q, got %v", c.expected, err)
+			}
+		})
+	}
+
+	// Links within the archive are allowed
+	path := writeTarGz(t, []tar.Header{
+		{Name: "modules/a/main.tf", Size: 1, Typeflag: tar.TypeReg},
+		{Name: "modules/b", Linkname: "a", Typeflag: tar.TypeSymlink},
+	})
+	if _, err := ValidateArchive(path); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
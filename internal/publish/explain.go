@@ -0,0 +1,268 @@
+package publish
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultIgnoreSource names the rules go-slug applies to every module.
+const defaultIgnoreSource = "default rules"
+
+// Explanation describes why a path is or is not packed.
+type Explanation struct {
+	// Path is relative to the module directory, using forward slashes.
+	// Directories end with a slash.
+	Path     string
+	Included bool
+	// Rule is the ignore rule that decided whether the path is packed, or
+	// nil if none did.
+	Rule   *IgnoreRule
+	Reason string
+	// Target is set when the path is packed as a copy of a file outside of
+	// the module directory, because it is, or is inside, a symlink that was
+	// dereferenced.
+	Target string
+}
+
+// Explanations are the explanations of every path in a module directory,
+// sorted by path.
+type Explanations []Explanation
+
+// Explain packs dir the same way PackAsFile does, and explains for every path
+// in it, and every path packed from outside it through a symlink, whether it
+// is packed and why. The contents of directories that are excluded entirely
+// are not listed.
+func Explain(dir string, options PackOptions) (Explanations, error) {
+	packed, kept, err := packEntries(dir, options)
+	if err != nil {
+		return nil, err
+	}
+	// Only the headers of the entries are needed
+	packed.Close()
+
+	terraformIgnore, err := loadTerraformIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newPackFilter(dir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &explainer{
+		dir:             dir,
+		terraformIgnore: terraformIgnore,
+		filter:          filter,
+		packed:          map[string]bool{},
+		kept:            map[string]bool{},
+		packedDirs:      map[string]bool{},
+	}
+	names := map[string]bool{}
+	for _, entry := range packed.entries {
+		e.packed[entry.header.Name] = true
+		names[entry.header.Name] = true
+		for d := path.Dir(strings.TrimSuffix(entry.header.Name, "/")); d != "."; d = path.Dir(d) {
+			e.packedDirs[d+"/"] = true
+		}
+	}
+	for _, entry := range kept {
+		e.kept[entry.header.Name] = true
+	}
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.IsDir() {
+			name += "/"
+		}
+		names[name] = true
+
+		// Nothing in the directory was packed, so its contents don't need
+		// explaining
+		if d.IsDir() && !e.packed[name] && !e.packedDirs[name] {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan module directory: %w", err)
+	}
+
+	explanations := make(Explanations, 0, len(names))
+	for name := range names {
+		explanations = append(explanations, e.explain(name))
+	}
+	sort.Slice(explanations, func(i, j int) bool {
+		return explanations[i].Path < explanations[j].Path
+	})
+	return explanations, nil
+}
+
+// Select returns the explanations of name, a path relative to the module
+// directory, and everything in it if it is a directory. If name is inside a
+// directory that was excluded entirely, the directory's explanation is
+// returned instead. It returns nil if the path is unknown.
+func (explanations Explanations) Select(name string) Explanations {
+	name = strings.TrimSuffix(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "." || name == "" {
+		return explanations
+	}
+
+	var selected Explanations
+	for _, explanation := range explanations {
+		if explanation.Path == name || strings.HasPrefix(explanation.Path, name+"/") {
+			selected = append(selected, explanation)
+		}
+	}
+	if len(selected) > 0 {
+		return selected
+	}
+
+	for d := path.Dir(name); d != "."; d = path.Dir(d) {
+		for _, explanation := range explanations {
+			if explanation.Path == d+"/" && !explanation.Included {
+				return Explanations{explanation}
+			}
+		}
+	}
+	return nil
+}
+
+type explainer struct {
+	dir             string
+	terraformIgnore IgnoreRules
+	filter          *packFilter
+	packed          map[string]bool
+	kept            map[string]bool
+	// packedDirs are the directories containing packed entries.
+	packedDirs map[string]bool
+}
+
+func (e *explainer) explain(name string) Explanation {
+	explanation := Explanation{Path: name, Included: e.kept[name], Target: e.externalTarget(name)}
+	isDir := strings.HasSuffix(name, "/")
+
+	switch {
+	case !e.packed[name]:
+		if rule := e.terraformIgnore.ExcludedBy(name); rule != nil {
+			explanation.Rule = rule
+			explanation.Reason = "excluded by " + rule.String()
+		} else if e.packedDirs[name+"/"] && explanation.Target != "" {
+			// go-slug packs the contents of a dereferenced directory
+			// without an entry for the symlink itself
+			explanation.Included = true
+			explanation.Reason = "symlink to a directory outside of the module, packed as a copy of it"
+		} else if isDir && e.packedDirs[name] {
+			explanation.Included = true
+			explanation.Reason = "directory with included files"
+		} else {
+			explanation.Reason = "not packed: symlinks to paths that don't exist, and files other than regular files, directories and symlinks, are skipped"
+		}
+	case !e.kept[name]:
+		if rule := e.filter.exclude.ExcludedBy(name); rule != nil {
+			explanation.Rule = rule
+			explanation.Reason = "excluded by " + rule.String()
+		} else if isDir {
+			explanation.Reason = "excluded: directory with no included files"
+		} else {
+			explanation.Reason = "excluded: does not match any include rule"
+		}
+	default:
+		explanation.Reason = "included"
+		if rule := e.negatedBy(name, isDir); rule != nil {
+			explanation.Rule = rule
+			explanation.Reason = "included: re-included by " + rule.String()
+		} else if rule := e.filter.include.IncludedBy(name); rule != nil && !isDir {
+			explanation.Rule = rule
+			explanation.Reason = "included by " + rule.String()
+		}
+	}
+
+	if explanation.Included && explanation.Target != "" && e.packed[name] {
+		explanation.Reason += "; packed as a copy of a file outside of the module"
+	}
+	return explanation
+}
+
+// negatedBy returns the negated rule that applies to name last, if any.
+func (e *explainer) negatedBy(name string, isDir bool) *IgnoreRule {
+	if rule := e.filter.exclude.Match(name, isDir); rule != nil && rule.Negated {
+		return rule
+	}
+	if rule := e.terraformIgnore.Match(name, isDir); rule != nil && rule.Negated && rule.Source != defaultIgnoreSource {
+		return rule
+	}
+	return nil
+}
+
+// externalTarget returns the path outside of the module directory that name
+// resolves to through a symlink, or an empty string.
+func (e *explainer) externalTarget(name string) string {
+	root, err := filepath.Abs(e.dir)
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	for i := range parts {
+		p := filepath.Join(root, filepath.Join(parts[:i+1]...))
+		info, err := os.Lstat(p)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		resolved, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			return ""
+		}
+		// Links within the module are packed as links
+		if rel, err := filepath.Rel(root, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return filepath.Join(append([]string{resolved}, parts[i+1:]...)...)
+	}
+	return ""
+}
+
+// loadTerraformIgnore returns the rules go-slug applies to the module in dir:
+// its default rules, followed by the module's .terraformignore, if it has one.
+func loadTerraformIgnore(dir string) (IgnoreRules, error) {
+	var rules IgnoreRules
+	for _, pattern := range []string{".terraform/", "!.terraform/modules/", ".git/"} {
+		rule, err := NewIgnoreRule(pattern, defaultIgnoreSource, 0)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	file, err := os.Open(filepath.Join(dir, ".terraformignore"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	parsed, err := ParseIgnoreFile(file, ".terraformignore")
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, parsed...), nil
+}
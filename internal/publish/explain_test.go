@@ -0,0 +1,126 @@
+package publish
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "shared.tf"), []byte("# shared\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.tf":           "# main\n",
+		"README.md":         "# readme\n",
+		"NOTES.md":          "# notes\n",
+		"debug.log":         "log\n",
+		"test/main.tf":      "# test\n",
+		".git/HEAD":         "ref: refs/heads/main\n",
+		".gitignore":        "*.log\n",
+		".terraformignore":  "*.md\n!README.md\n",
+		"modules/a/main.tf": "# a\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "shared.tf"), filepath.Join(dir, "shared.tf")); err != nil {
+		t.Fatal(err)
+	}
+
+	exclude, err := NewIgnoreRule("test/", "--exclude", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanations, err := Explain(dir, PackOptions{GitIgnore: true, Exclude: IgnoreRules{exclude}})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := map[string]string{
+		".git/":             "excluded by default rules: .git/",
+		"NOTES.md":          "excluded by .terraformignore:1: *.md",
+		"README.md":         "included: re-included by .terraformignore:2: !README.md",
+		"debug.log":         "excluded by .gitignore:1: *.log",
+		"main.tf":           "included",
+		"modules/a/main.tf": "included",
+		"shared.tf":         "included; packed as a copy of a file outside of the module",
+		"test/":             "excluded by --exclude: test/",
+		"test/main.tf":      "excluded by --exclude: test/",
+	}
+	found := map[string]Explanation{}
+	for _, explanation := range explanations {
+		found[explanation.Path] = explanation
+	}
+	for path, reason := range expected {
+		explanation, ok := found[path]
+		if !ok {
+			t.Errorf("expected an explanation of %s", path)
+			continue
+		}
+		if explanation.Reason != reason {
+			t.Errorf("%s: expected %q, got %q", path, reason, explanation.Reason)
+		}
+		if explanation.Included != strings.HasPrefix(reason, "included") {
+			t.Errorf("%s: unexpected included %t", path, explanation.Included)
+		}
+	}
+
+	if _, ok := found[".git/HEAD"]; ok {
+		t.Error("expected the contents of .git/ not to be listed")
+	}
+	if target, _ := filepath.EvalSymlinks(filepath.Join(outside, "shared.tf")); found["shared.tf"].Target != target {
+		t.Errorf("expected the target of shared.tf to be %s, got %q", target, found["shared.tf"].Target)
+	}
+	if rule := found["NOTES.md"].Rule; rule == nil || rule.Line != 1 {
+		t.Errorf("unexpected rule %v", rule)
+	}
+
+	if selected := explanations.Select(".git/HEAD"); len(selected) != 1 || selected[0].Path != ".git/" {
+		t.Errorf("expected .git/HEAD to be explained by .git/, got %v", selected)
+	}
+	if selected := explanations.Select("modules"); len(selected) != 3 {
+		t.Errorf("expected modules/, modules/a/ and modules/a/main.tf, got %v", selected)
+	}
+	if selected := explanations.Select("missing.tf"); selected != nil {
+		t.Errorf("expected no explanations, got %v", selected)
+	}
+}
+
+func TestExplainMatchesPack(t *testing.T) {
+	include, err := NewIgnoreRule("*.tf", "--include", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := PackOptions{Include: IgnoreRules{include}}
+
+	explanations, err := Explain("./fixtures/moduleA", options)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	archive, kept, err := packEntries("./fixtures/moduleA", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive.Close()
+
+	packed := map[string]bool{}
+	for _, entry := range kept {
+		packed[entry.header.Name] = true
+	}
+	for _, explanation := range explanations {
+		if explanation.Included != packed[explanation.Path] {
+			t.Errorf("%s: explained as included %t, but packed %t", explanation.Path, explanation.Included, packed[explanation.Path])
+		}
+	}
+}
@@ -0,0 +1,8 @@
+resource "null_resource" "foo" {
+  triggers = {
+    hello = "world"
+  }
+}
+
+variable "missing_brace" {
+  type = string
@@ -0,0 +1,269 @@
+package publish
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreRule is a pattern that matches paths in a module directory, in
+// .terraformignore syntax: "*" and "?" match within a path segment, "**"
+// matches any number of segments, a trailing "/" matches a directory and
+// everything in it, a leading "/" anchors the pattern to the module
+// directory, and a leading "!" re-includes paths excluded by an earlier
+// rule.
+type IgnoreRule struct {
+	// Pattern is the rule as written, without the "!" of a negated rule.
+	Pattern string
+	Negated bool
+	// Source is where the rule was configured, Ex: ".gitignore" or
+	// "--exclude", and Line is its line in that file, or 0.
+	Source string
+	Line   int
+
+	regex *regexp.Regexp
+}
+
+func (r *IgnoreRule) String() string {
+	pattern := r.Pattern
+	if r.Negated {
+		pattern = "!" + pattern
+	}
+	if r.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", r.Source, r.Line, pattern)
+	}
+	return fmt.Sprintf("%s: %s", r.Source, pattern)
+}
+
+// NewIgnoreRule parses a pattern configured in source. A pattern starting
+// with "!" is negated.
+func NewIgnoreRule(pattern, source string, line int) (*IgnoreRule, error) {
+	return newIgnoreRule(pattern, source, line, "", false)
+}
+
+// newIgnoreRule parses a pattern that applies to the paths under base, a
+// directory relative to the module directory. When anchorSlash is set, a
+// pattern with a "/" other than a trailing one is anchored to base, as in
+// .gitignore files; otherwise it matches at any depth, as in
+// .terraformignore.
+func newIgnoreRule(pattern, source string, line int, base string, anchorSlash bool) (*IgnoreRule, error) {
+	rule := &IgnoreRule{Pattern: pattern, Source: source, Line: line}
+	if strings.HasPrefix(pattern, "!") {
+		rule.Negated = true
+		pattern = pattern[1:]
+		rule.Pattern = pattern
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern in %s", source)
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	anchored := strings.HasPrefix(pattern, "/")
+	if anchorSlash && strings.Contains(strings.TrimSuffix(pattern, "/**"), "/") {
+		anchored = true
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !anchored {
+		pattern = "**/" + pattern
+	}
+	if base != "" && base != "." {
+		pattern = base + "/" + pattern
+	}
+
+	regex, err := compilePattern(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q in %s: %w", rule.Pattern, source, err)
+	}
+	rule.regex = regex
+	return rule, nil
+}
+
+// compilePattern converts a pattern to a regular expression the same way
+// go-slug does for .terraformignore.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch ch := runes[i]; {
+		case ch == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			i++
+			// Treat "**/" as "**"
+			if i+1 < len(runes) && runes[i+1] == '/' {
+				i++
+			}
+			if i+1 == len(runes) {
+				b.WriteString(".*")
+			} else {
+				b.WriteString("(.*/)?")
+			}
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '.' || ch == '$':
+			b.WriteString(`\` + string(ch))
+		case ch == '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(`\` + string(runes[i]))
+			} else {
+				b.WriteString(`\\`)
+			}
+		default:
+			b.WriteRune(ch)
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// IgnoreRules is an ordered list of rules. The last rule that matches a path
+// decides whether it is excluded.
+type IgnoreRules []*IgnoreRule
+
+// Match returns the last rule that matches name, a slash-separated path
+// relative to the module directory, or nil if none does. A directory matches
+// either by its name or by its name with a trailing slash.
+func (rules IgnoreRules) Match(name string, isDir bool) *IgnoreRule {
+	if i := rules.match(name, isDir); i >= 0 {
+		return rules[i]
+	}
+	return nil
+}
+
+func (rules IgnoreRules) match(name string, isDir bool) int {
+	name = strings.TrimSuffix(name, "/")
+	matched := -1
+	for i, rule := range rules {
+		if rule.regex.MatchString(name) || (isDir && rule.regex.MatchString(name+"/")) {
+			matched = i
+		}
+	}
+	return matched
+}
+
+// Excludes reports whether the rules exclude name.
+func (rules IgnoreRules) Excludes(name string, isDir bool) bool {
+	rule := rules.Match(name, isDir)
+	return rule != nil && !rule.Negated
+}
+
+// ExcludedBy returns the rule that excludes name, either directly or by
+// excluding a directory containing it, or nil if it is not excluded. Like
+// go-slug, the contents of an excluded directory are only excluded with it if
+// no negated rule follows the one that matched the directory.
+func (rules IgnoreRules) ExcludedBy(name string) *IgnoreRule {
+	parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		if j := rules.match(dir, true); j >= 0 && !rules[j].Negated && !rules.negatedAfter(j) {
+			return rules[j]
+		}
+	}
+
+	if rule := rules.Match(name, strings.HasSuffix(name, "/")); rule != nil && !rule.Negated {
+		return rule
+	}
+	return nil
+}
+
+// IncludedBy returns the rule that matches name or a directory containing
+// it, or nil if there is none.
+func (rules IgnoreRules) IncludedBy(name string) *IgnoreRule {
+	parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	for i := 1; i <= len(parts); i++ {
+		isDir := i < len(parts) || strings.HasSuffix(name, "/")
+		if rule := rules.Match(strings.Join(parts[:i], "/"), isDir); rule != nil && !rule.Negated {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (rules IgnoreRules) negatedAfter(i int) bool {
+	for _, rule := range rules[i+1:] {
+		if rule.Negated {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIgnoreFile reads rules from an ignore file. Blank lines and lines
+// starting with "#" are skipped.
+func ParseIgnoreFile(r io.Reader, source string) (IgnoreRules, error) {
+	return parseIgnoreFile(r, source, "", false)
+}
+
+func parseIgnoreFile(r io.Reader, source, base string, anchorSlash bool) (IgnoreRules, error) {
+	var rules IgnoreRules
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		rule, err := newIgnoreRule(pattern, source, line, base, anchorSlash)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	return rules, nil
+}
+
+// loadGitIgnore reads the .gitignore files in dir and its subdirectories.
+// The rules in each file apply to the paths in its directory.
+func loadGitIgnore(dir string) (IgnoreRules, error) {
+	var rules IgnoreRules
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && (d.Name() == ".git" || d.Name() == ".terraform") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != ".gitignore" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		parsed, err := parseIgnoreFile(file, rel, path.Dir(rel), true)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, parsed...)
+		return nil
+	})
+	return rules, err
+}
@@ -0,0 +1,123 @@
+package publish
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	rules, err := ParseIgnoreFile(strings.NewReader("# comment\n\n*.md\n!README.md\n/build\ndocs/\ntest/*.tf\n"), ".terraformignore")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(rules) != 5 {
+		t.Fatalf("expected 5 rules, got %d", len(rules))
+	}
+
+	cases := map[string]bool{
+		"CHANGELOG.md":          true,
+		"modules/a/NOTES.md":    true,
+		"README.md":             false,
+		"build":                 true,
+		"modules/build":         false,
+		"docs/":                 true,
+		"docs/index.html":       true,
+		"modules/a/docs/x.html": true,
+		"test/main.tf":          true,
+		"modules/test/main.tf":  true,
+		"main.tf":               false,
+	}
+	for name, excluded := range cases {
+		if actual := rules.Excludes(name, strings.HasSuffix(name, "/")); actual != excluded {
+			t.Errorf("%s: expected excluded %t, got %t", name, excluded, actual)
+		}
+	}
+
+	if rule := rules.Match("README.md", false); rule == nil || !rule.Negated || rule.Line != 4 {
+		t.Errorf("expected README.md to match the negated rule on line 4, got %v", rule)
+	}
+	if rule := rules.Match("CHANGELOG.md", false); rule.String() != ".terraformignore:3: *.md" {
+		t.Errorf("unexpected rule %q", rule)
+	}
+}
+
+func TestIgnoreRulesExcludedBy(t *testing.T) {
+	build, _ := NewIgnoreRule("build", "--exclude", 0)
+	keep, _ := NewIgnoreRule("!keep.tf", "--exclude", 0)
+
+	rules := IgnoreRules{build}
+	if rule := rules.ExcludedBy("build/out/main.tf"); rule != build {
+		t.Errorf("expected files in an excluded directory to be excluded, got %v", rule)
+	}
+
+	// A later negation means the directory's contents are matched one by one
+	rules = IgnoreRules{build, keep}
+	if rule := rules.ExcludedBy("build/keep.tf"); rule != nil {
+		t.Errorf("expected build/keep.tf to be included, got %v", rule)
+	}
+	if rule := rules.ExcludedBy("build"); rule != build {
+		t.Errorf("expected build to be excluded, got %v", rule)
+	}
+}
+
+func TestIgnoreRulesIncludedBy(t *testing.T) {
+	modules, _ := NewIgnoreRule("modules", "--include", 0)
+	tf, _ := NewIgnoreRule("*.tf", "--include", 0)
+	rules := IgnoreRules{modules, tf}
+
+	if rule := rules.IncludedBy("modules/a/README.md"); rule != modules {
+		t.Errorf("expected files in an included directory to be included, got %v", rule)
+	}
+	if rule := rules.IncludedBy("examples/basic/main.tf"); rule != tf {
+		t.Errorf("expected main.tf to be included, got %v", rule)
+	}
+	if rule := rules.IncludedBy("README.md"); rule != nil {
+		t.Errorf("expected README.md not to be included, got %v", rule)
+	}
+}
+
+func TestLoadGitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".gitignore":         "*.log\n/dist/\n",
+		"modules/.gitignore": "generated/main.tf\n",
+		".git/info/exclude":  "*.tf\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, err := loadGitIgnore(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	cases := map[string]bool{
+		"debug.log":                 true,
+		"modules/a/debug.log":       true,
+		"dist/":                     true,
+		"modules/dist/":             false,
+		"modules/generated/main.tf": true,
+		// Patterns with a slash are anchored to the .gitignore's directory
+		"modules/a/generated/main.tf": false,
+		"generated/main.tf":           false,
+		"main.tf":                     false,
+	}
+	for name, excluded := range cases {
+		if actual := rules.Excludes(name, strings.HasSuffix(name, "/")); actual != excluded {
+			t.Errorf("%s: expected excluded %t, got %t", name, excluded, actual)
+		}
+	}
+
+	if rule := rules.Match("modules/generated/main.tf", false); rule.Source != "modules/.gitignore" || rule.Line != 1 {
+		t.Errorf("unexpected rule %v", rule)
+	}
+}
@@ -1,39 +1,354 @@
 package publish
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-slug"
 )
 
-func slugDirectoryToFile(dir string, writer io.Writer) (int64, error) {
-	packer, err := slug.NewPacker(slug.ApplyTerraformIgnore(), slug.DereferenceSymlinks())
+// PackOptions control which files are packed, in addition to the module's
+// .terraformignore file. Patterns use .terraformignore syntax; see
+// IgnoreRule.
+type PackOptions struct {
+	// Include, if not empty, limits the archive to the paths that match at
+	// least one of the rules.
+	Include IgnoreRules
+	// Exclude removes the paths that match the rules from the archive.
+	Exclude IgnoreRules
+	// GitIgnore also excludes the paths ignored by .gitignore files in the
+	// module directory, before the Exclude rules are applied.
+	GitIgnore bool
+	// PreserveSymlinks refuses to pack symlinks with a target outside of the
+	// module directory, rather than packing a copy of their target.
+	PreserveSymlinks bool
+
+	// output is the slash-separated path of the archive being written, if it
+	// is inside the module directory, so that it is not packed into itself.
+	output string
+}
+
+// packFilter decides which entries packed by go-slug are kept.
+type packFilter struct {
+	include IgnoreRules
+	exclude IgnoreRules
+	output  string
+}
+
+func newPackFilter(dir string, options PackOptions) (*packFilter, error) {
+	filter := &packFilter{include: options.Include, output: options.output}
+	if options.GitIgnore {
+		rules, err := loadGitIgnore(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read .gitignore files: %w", err)
+		}
+		filter.exclude = append(filter.exclude, rules...)
+	}
+	filter.exclude = append(filter.exclude, options.Exclude...)
+	return filter, nil
+}
+
+// keeps reports whether the entry name is kept. Directories are kept if they
+// are not excluded; filterEntries also removes those left empty.
+func (f *packFilter) keeps(name string) bool {
+	if name == f.output || f.exclude.ExcludedBy(name) != nil {
+		return false
+	}
+	if strings.HasSuffix(name, "/") || len(f.include) == 0 {
+		return true
+	}
+	return f.include.IncludedBy(name) != nil
+}
+
+// slugDirectoryToFile packs dir into a deterministic archive written to
+// writer, and returns the total size of the files in it, uncompressed.
+func slugDirectoryToFile(dir string, writer io.Writer, options PackOptions) (int64, error) {
+	packed, entries, err := packEntries(dir, options)
+	if err != nil {
+		return 0, err
+	}
+	defer packed.Close()
+
+	if err := writeNormalizedArchive(packed, entries, writer); err != nil {
+		return 0, fmt.Errorf("failed to normalize archive: %w", err)
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if entry.header.Typeflag == tar.TypeReg {
+			size += entry.header.Size
+		}
+	}
+	return size, nil
+}
+
+// packEntries packs dir with go-slug, which applies .terraformignore and
+// handles symlinks, then applies the rest of the options. It returns the
+// archive packed by go-slug, which the caller must close, and the entries of
+// it that are kept.
+func packEntries(dir string, options PackOptions) (*packedArchive, []archiveEntry, error) {
+	packerOptions := []slug.PackerOption{slug.ApplyTerraformIgnore()}
+	if !options.PreserveSymlinks {
+		packerOptions = append(packerOptions, slug.DereferenceSymlinks())
+	}
+	packer, err := slug.NewPacker(packerOptions...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to init slug packer. %w", err)
+	}
+
+	filter, err := newPackFilter(dir, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	packed, err := spoolArchive(func(w io.Writer) error {
+		_, err := packer.Pack(dir, w)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kept, err := filterEntries(packed.entries, filter)
 	if err != nil {
-		return 0, fmt.Errorf("failed to init slug packer. %w", err)
+		packed.Close()
+		return nil, nil, err
+	}
+	return packed, kept, nil
+}
+
+// filterEntries removes the entries that filter does not keep. With include
+// rules, directories left without any entries are removed too. It fails if a
+// kept symlink points to an entry that was removed.
+func filterEntries(entries []archiveEntry, filter *packFilter) ([]archiveEntry, error) {
+	kept := map[string]bool{}
+	var files []archiveEntry
+	for _, entry := range entries {
+		name := entry.header.Name
+		if entry.header.Typeflag == tar.TypeDir || !filter.keeps(name) {
+			continue
+		}
+		kept[name] = true
+		files = append(files, entry)
+		// Keep the directories containing the file
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			kept[dir+"/"] = true
+		}
+	}
+
+	var result []archiveEntry
+	for _, entry := range entries {
+		name := entry.header.Name
+		if entry.header.Typeflag == tar.TypeDir {
+			// Without include rules, empty directories are kept as before
+			if (kept[name] || len(filter.include) == 0) && filter.keeps(name) {
+				result = append(result, entry)
+			}
+			continue
+		}
+		if kept[name] {
+			result = append(result, entry)
+		}
+	}
+
+	for _, entry := range files {
+		if entry.header.Typeflag != tar.TypeSymlink {
+			continue
+		}
+		if path.IsAbs(entry.header.Linkname) {
+			continue
+		}
+		target := path.Join(path.Dir(entry.header.Name), entry.header.Linkname)
+		if !kept[target] && !kept[target+"/"] {
+			return nil, fmt.Errorf("symlink %q points to %q, which is not in the archive", entry.header.Name, entry.header.Linkname)
+		}
 	}
 
-	meta, err := packer.Pack(dir, writer)
+	return result, nil
+}
+
+// archiveEntry is the header of an entry in a packedArchive, and the offset
+// of its contents in the archive's file.
+type archiveEntry struct {
+	header *tar.Header
+	offset int64
+}
+
+// packedArchive is an uncompressed tarball in a temporary file, indexed so
+// that the contents of its entries can be read in any order without holding
+// them in memory.
+type packedArchive struct {
+	file    *os.File
+	entries []archiveEntry
+}
+
+// spoolArchive decompresses the gzipped tarball written by pack into a
+// temporary file and indexes its entries.
+func spoolArchive(pack func(io.Writer) error) (*packedArchive, error) {
+	file, err := os.CreateTemp("", "rt-slug")
 	if err != nil {
-		return 0, fmt.Errorf("failed to pack specified directory: %w", err)
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
 	}
+	archive := &packedArchive{file: file}
+
+	r, w := io.Pipe()
+	packErr := make(chan error, 1)
+	go func() {
+		err := pack(w)
+		w.CloseWithError(err)
+		packErr <- err
+	}()
 
-	return meta.Size, nil
+	copyErr := decompress(file, r)
+	// Unblocks pack if the archive could not be read to the end
+	r.Close()
+	if err := <-packErr; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		archive.Close()
+		return nil, fmt.Errorf("failed to pack specified directory: %w", err)
+	}
+	if copyErr != nil {
+		archive.Close()
+		return nil, fmt.Errorf("failed to read packed archive: %w", copyErr)
+	}
+
+	if err := archive.index(); err != nil {
+		archive.Close()
+		return nil, fmt.Errorf("failed to read packed archive: %w", err)
+	}
+	return archive, nil
+}
+
+func decompress(w io.Writer, r io.Reader) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+
+	_, err = io.Copy(w, gzr)
+	return err
+}
+
+// index records the header and the offset of the contents of every entry.
+// The tar reader reads the file directly, so after each header the file is
+// positioned at the entry's contents.
+func (a *packedArchive) index() error {
+	if _, err := a.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(a.file)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		offset, err := a.file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		a.entries = append(a.entries, archiveEntry{header: header, offset: offset})
+	}
+}
+
+// contents returns a reader of the contents of entry.
+func (a *packedArchive) contents(entry archiveEntry) io.Reader {
+	return io.NewSectionReader(a.file, entry.offset, entry.header.Size)
+}
+
+// Close removes the archive's temporary file.
+func (a *packedArchive) Close() error {
+	err := a.file.Close()
+	if removeErr := os.Remove(a.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// writeNormalizedArchive writes entries of packed as a gzipped tarball so
+// that packing the same files always produces the same bytes: entries are
+// sorted by name, timestamps and ownership are removed, modes are reduced to
+// 0644 or 0755, and the gzip header carries no name or modification time.
+// The contents of each entry are copied from packed in turn.
+func writeNormalizedArchive(packed *packedArchive, entries []archiveEntry, w io.Writer) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].header.Name < entries[j].header.Name
+	})
+
+	gzw, err := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	// Unknown OS, rather than whatever the host is
+	gzw.Header.OS = 255
+
+	tw := tar.NewWriter(gzw)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Typeflag: entry.header.Typeflag,
+			Name:     entry.header.Name,
+			Linkname: entry.header.Linkname,
+			Size:     entry.header.Size,
+			Mode:     normalizeMode(entry.header),
+			ModTime:  time.Unix(0, 0),
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if entry.header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if _, err := io.Copy(tw, packed.contents(entry)); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// normalizeMode keeps only whether a file is executable.
+func normalizeMode(header *tar.Header) int64 {
+	switch {
+	case header.Typeflag == tar.TypeDir, header.Mode&0111 != 0:
+		return 0755
+	case header.Typeflag == tar.TypeSymlink:
+		return 0777
+	default:
+		return 0644
+	}
 }
 
 // PackAsFile slugs the specified directory as a temp file. It is the caller's
 // responsibility to close and remove the file after it is used. The file is
 // returned ready to be read, seeked to offset 0.
-func PackAsFile(dir string) (string, int64, error) {
+func PackAsFile(dir string, options PackOptions) (string, int64, error) {
 	file, err := os.CreateTemp("", "slug")
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to create temp file. %w", err)
 	}
 	defer file.Close()
 
-	size, err := slugDirectoryToFile(dir, file)
+	size, err := slugDirectoryToFile(dir, file, options)
 	if err != nil {
 		return file.Name(), size, err
 	}
@@ -47,3 +362,101 @@ func PackAsFile(dir string) (string, int64, error) {
 
 	return file.Name(), size, nil
 }
+
+// PackToFile packs dir into a new archive at output, replacing any existing
+// file, and returns the total size of the files in it, uncompressed. The
+// archive is written to a temporary file outside of dir and then moved to
+// output, so a partial archive is never left at output, and an output inside
+// dir is not packed into itself.
+func PackToFile(dir, output string, options PackOptions) (int64, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+	absOutput, err := filepath.Abs(output)
+	if err != nil {
+		return 0, err
+	}
+
+	tempDir := filepath.Dir(absOutput)
+	if rel, err := filepath.Rel(absDir, absOutput); err == nil && filepath.IsLocal(rel) {
+		options.output = filepath.ToSlash(rel)
+		tempDir = ""
+	}
+
+	file, err := os.CreateTemp(tempDir, "rt-pack")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	size, err := slugDirectoryToFile(dir, file, options)
+	if err == nil {
+		// Temp files are only readable by their owner
+		err = file.Chmod(0644)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(file.Name(), output); err != nil {
+		// The temp directory may be on another file system
+		if err := copyFile(file.Name(), output); err != nil {
+			return 0, fmt.Errorf("failed to write %q: %w", output, err)
+		}
+	}
+	return size, nil
+}
+
+// copyFile copies the contents of the file at src to a new file at dst,
+// replacing any existing file.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// FileSHA256 returns the hex encoded SHA-256 digest of the file at path.
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest, err := ReaderSHA256(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %q: %w", path, err)
+	}
+	return digest, nil
+}
+
+// ReaderSHA256 returns the hex encoded SHA-256 digest of the contents of
+// reader, and seeks it back to the beginning.
+func ReaderSHA256(reader io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
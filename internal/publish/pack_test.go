@@ -1,14 +1,21 @@
 package publish_test
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/registry-tools/rt-cli/internal/publish"
 )
 
 func TestPackAsFile(t *testing.T) {
-	file, size, err := publish.PackAsFile("./fixtures/moduleA")
+	file, size, err := publish.PackAsFile("./fixtures/moduleA", publish.PackOptions{})
 	t.Cleanup(func() {
 		if file != "" {
 			os.Remove(file)
@@ -23,3 +30,273 @@ func TestPackAsFile(t *testing.T) {
 		t.Errorf("expected size greater than 0, got %d", size)
 	}
 }
+
+func TestPackIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.tf", "variables.tf", "modules/a/main.tf"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# "+name+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pack := func() string {
+		output := filepath.Join(t.TempDir(), "module.tar.gz")
+		if _, err := publish.PackToFile(dir, output, publish.PackOptions{}); err != nil {
+			t.Fatalf("expected no error, got %s", err)
+		}
+		digest, err := publish.FileSHA256(output)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err)
+		}
+		return digest
+	}
+
+	first := pack()
+
+	// Timestamps and permissions other than the executable bit don't matter
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "main.tf"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "variables.tf"), 0664); err != nil {
+		t.Fatal(err)
+	}
+
+	if second := pack(); first != second {
+		t.Errorf("expected packing twice to produce the same archive, got %s and %s", first, second)
+	}
+
+	if err := os.Chmod(filepath.Join(dir, "main.tf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if third := pack(); first == third {
+		t.Errorf("expected the executable bit to change the archive")
+	}
+}
+
+// writeFiles creates files with their names as content in dir.
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// archiveNames returns the names of the entries in the archive at path.
+func archiveNames(t *testing.T, path string) []string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+}
+
+func TestPackContents(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.tf":           "# main\n",
+		"b/large.txt":       strings.Repeat("0123456789abcdef", 1<<16),
+		"a/nested/empty.tf": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(t.TempDir(), "module.tar.gz")
+	if _, err := publish.PackToFile(dir, output, publish.PackOptions{}); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(gzr)
+	found := 0
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != files[header.Name] {
+			t.Errorf("unexpected contents of %s (%d bytes)", header.Name, len(content))
+		}
+		found++
+	}
+	if found != len(files) {
+		t.Errorf("expected %d files, got %d", len(files), found)
+	}
+}
+
+func TestPackToFileInsideModule(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "main.tf")
+	output := filepath.Join(dir, "module.tar.gz")
+
+	// Packing again must not pack the previous archive
+	for i := 0; i < 2; i++ {
+		if _, err := publish.PackToFile(dir, output, publish.PackOptions{}); err != nil {
+			t.Fatalf("expected no error, got %s", err)
+		}
+		if names := strings.Join(archiveNames(t, output), ","); names != "main.tf" {
+			t.Errorf("expected only main.tf to be packed, got %s", names)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected no temporary files to be left in the module directory, got %v", entries)
+	}
+}
+
+func TestPackOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"main.tf", "README.md", "debug.log", ".gitignore", ".terraformignore",
+		"modules/a/main.tf", "modules/a/NOTES.md", "test/main_test.tf", "dist/bundle.zip",
+	)
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\ndist/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".terraformignore"), []byte("*.zip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := func(pattern string) *publish.IgnoreRule {
+		r, err := publish.NewIgnoreRule(pattern, "test", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	cases := map[string]struct {
+		options  publish.PackOptions
+		expected string
+	}{
+		"defaults": {
+			publish.PackOptions{},
+			".gitignore,.terraformignore,README.md,debug.log,dist/,main.tf,modules/,modules/a/,modules/a/NOTES.md,modules/a/main.tf,test/,test/main_test.tf",
+		},
+		"exclude": {
+			publish.PackOptions{Exclude: publish.IgnoreRules{rule("test/"), rule("*.md"), rule("!README.md")}},
+			".gitignore,.terraformignore,README.md,debug.log,dist/,main.tf,modules/,modules/a/,modules/a/main.tf",
+		},
+		"gitignore": {
+			publish.PackOptions{GitIgnore: true},
+			".gitignore,.terraformignore,README.md,main.tf,modules/,modules/a/,modules/a/NOTES.md,modules/a/main.tf,test/,test/main_test.tf",
+		},
+		"include": {
+			publish.PackOptions{Include: publish.IgnoreRules{rule("*.tf"), rule("README.md")}, Exclude: publish.IgnoreRules{rule("test/")}},
+			"README.md,main.tf,modules/,modules/a/,modules/a/main.tf",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "module.tar.gz")
+			size, err := publish.PackToFile(dir, output, c.options)
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+			if names := strings.Join(archiveNames(t, output), ","); names != c.expected {
+				t.Errorf("expected entries:\n%s\ngot:\n%s", c.expected, names)
+			}
+			if size == 0 {
+				t.Error("expected a size greater than 0")
+			}
+		})
+	}
+}
+
+func TestPackSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	writeFiles(t, outside, "shared.tf")
+
+	dir := t.TempDir()
+	writeFiles(t, dir, "main.tf", "docs/README.md")
+	if err := os.Symlink(filepath.Join(outside, "shared.tf"), filepath.Join(dir, "shared.tf")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("docs/README.md", filepath.Join(dir, "README.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "module.tar.gz")
+	if _, err := publish.PackToFile(dir, output, publish.PackOptions{}); err != nil {
+		t.Fatalf("expected external symlinks to be dereferenced, got %s", err)
+	}
+	if names := strings.Join(archiveNames(t, output), ","); names != "README.md,docs/,docs/README.md,main.tf,shared.tf" {
+		t.Errorf("unexpected entries %s", names)
+	}
+
+	if _, err := publish.PackToFile(dir, output, publish.PackOptions{PreserveSymlinks: true}); err == nil {
+		t.Error("expected an error for a symlink outside of the module directory")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "shared.tf")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := publish.PackToFile(dir, output, publish.PackOptions{PreserveSymlinks: true}); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	excludeDocs, _ := publish.NewIgnoreRule("docs/", "test", 0)
+	_, err := publish.PackToFile(dir, output, publish.PackOptions{PreserveSymlinks: true, Exclude: publish.IgnoreRules{excludeDocs}})
+	if err == nil || !strings.Contains(err.Error(), "not in the archive") {
+		t.Errorf("expected an error for a symlink to an excluded file, got %v", err)
+	}
+}
@@ -130,7 +130,7 @@ func TestPackAndPublish(t *testing.T) {
 		SDK: sdk,
 	}
 
-	path, _, err := publish.PackAsFile("./fixtures/moduleA")
+	path, _, err := publish.PackAsFile("./fixtures/moduleA", publish.PackOptions{})
 	t.Cleanup(func() {
 		if path != "" {
 			os.Remove(path)
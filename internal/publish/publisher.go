@@ -15,6 +15,36 @@ import (
 // Publisher publishes modules to the registry.
 type Publisher struct {
 	SDK sdk.SDK
+
+	// Metadata is recorded with each published module version.
+	Metadata Metadata
+}
+
+// Metadata is additional information recorded with a published module
+// version.
+type Metadata struct {
+	// SourceRepository and SourceCommit identify the git commit that the
+	// module was published from.
+	SourceRepository string
+	SourceCommit     string
+
+	// ArchiveSHA256 is the hex encoded digest of the published archive.
+	ArchiveSHA256 string
+}
+
+// additionalData returns the attributes of the metadata that are set.
+func (m Metadata) additionalData() map[string]any {
+	data := map[string]any{}
+	if m.SourceRepository != "" {
+		data["source-repository"] = m.SourceRepository
+	}
+	if m.SourceCommit != "" {
+		data["source-commit"] = m.SourceCommit
+	}
+	if m.ArchiveSHA256 != "" {
+		data["archive-sha256"] = m.ArchiveSHA256
+	}
+	return data
 }
 
 type ModuleVersion struct {
@@ -50,6 +80,9 @@ func (p Publisher) Publish(ctx context.Context, info module.Module, reader io.Re
 	moduleBody.SetSystem(&info.System)
 	moduleBody.SetVersion(&info.Version)
 	moduleBody.SetArchiveId(signedID)
+	if data := p.Metadata.additionalData(); len(data) > 0 {
+		moduleBody.SetAdditionalData(data)
+	}
 
 	response, err := p.SDK.Api().TerraformModuleVersions().PostAsTerraformModuleVersionsPostResponse(ctx, moduleBody, nil)
 	if err != nil {
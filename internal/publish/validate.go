@@ -0,0 +1,145 @@
+package publish
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// Problem is an issue found in a module's source before it is published.
+type Problem struct {
+	Severity hcl.DiagnosticSeverity
+	Summary  string
+	Detail   string
+	// File is relative to the module directory, and is empty if the problem
+	// does not relate to a particular file.
+	File string
+	Line int
+}
+
+func (p Problem) Error() string {
+	var b strings.Builder
+	if p.File != "" {
+		fmt.Fprintf(&b, "%s:%d: ", p.File, p.Line)
+	}
+	b.WriteString(p.Summary)
+	if p.Detail != "" {
+		fmt.Fprintf(&b, "; %s", p.Detail)
+	}
+	return b.String()
+}
+
+// Problems is a list of problems found while validating a module.
+type Problems []Problem
+
+// HasErrors reports whether any of the problems should prevent publishing.
+func (p Problems) HasErrors() bool {
+	for _, problem := range p {
+		if problem.Severity == hcl.DiagError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate scans the Terraform configuration files in dir, including any
+// nested modules, and reports syntax errors and other problems that would
+// prevent the module from being used. Files excluded by the module's
+// .terraformignore are skipped, since they are not published.
+func Validate(dir string) (Problems, error) {
+	return ValidatePackage(dir, PackOptions{})
+}
+
+// ValidatePackage is like Validate, but also skips the files that packing dir
+// with options would leave out.
+func ValidatePackage(dir string, options PackOptions) (Problems, error) {
+	terraformIgnore, err := loadTerraformIgnore(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .terraformignore: %w", err)
+	}
+	filter, err := newPackFilter(dir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := hclparse.NewParser()
+	var problems Problems
+	rootFiles := 0
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if terraformIgnore.ExcludedBy(name) != nil || !filter.keeps(name) {
+			return nil
+		}
+
+		var diags hcl.Diagnostics
+		switch {
+		case strings.HasSuffix(path, ".tf"):
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			_, diags = parser.ParseHCL(src, rel)
+		case strings.HasSuffix(path, ".tf.json"):
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			_, diags = parser.ParseJSON(src, rel)
+		default:
+			return nil
+		}
+
+		if filepath.Dir(rel) == "." {
+			rootFiles++
+		}
+
+		for _, diag := range diags {
+			problem := Problem{
+				Severity: diag.Severity,
+				Summary:  diag.Summary,
+				Detail:   diag.Detail,
+				File:     rel,
+			}
+			if diag.Subject != nil {
+				problem.Line = diag.Subject.Start.Line
+			}
+			problems = append(problems, problem)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan module directory: %w", err)
+	}
+
+	if rootFiles == 0 {
+		problems = append(problems, Problem{
+			Severity: hcl.DiagError,
+			Summary:  "No Terraform configuration files",
+			Detail:   fmt.Sprintf("%s does not contain any .tf or .tf.json files", dir),
+		})
+	}
+
+	return problems, nil
+}
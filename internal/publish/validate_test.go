@@ -0,0 +1,89 @@
+package publish_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/registry-tools/rt-cli/internal/publish"
+)
+
+func TestValidate(t *testing.T) {
+	problems, err := publish.Validate("./fixtures/moduleA")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	problems, err := publish.Validate("./fixtures/invalid")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if !problems.HasErrors() {
+		t.Fatalf("expected errors, got %v", problems)
+	}
+
+	if problems[0].File != "main.tf" {
+		t.Errorf("expected problem in main.tf, got %q", problems[0].File)
+	}
+
+	if problems[0].Line == 0 {
+		t.Error("expected the problem to have a line number")
+	}
+}
+
+func TestValidateEmptyDirectory(t *testing.T) {
+	problems, err := publish.Validate(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if !problems.HasErrors() {
+		t.Error("expected an error for a directory without configuration files")
+	}
+}
+
+func TestValidateIgnoredFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.tf":           "variable \"name\" {}\n",
+		"scratch/broken.tf": "resource {\n",
+		"tests/broken.tf":   "resource {\n",
+		".terraformignore":  "scratch/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems, err := publish.Validate(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(problems) != 1 || problems[0].File != filepath.Join("tests", "broken.tf") {
+		t.Errorf("expected only the problem in tests/broken.tf, got %v", problems)
+	}
+
+	exclude, err := publish.NewIgnoreRule("tests/", "--exclude", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err = publish.ValidatePackage(dir, publish.PackOptions{Exclude: publish.IgnoreRules{exclude}})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected no problems in files that are not packed, got %v", problems)
+	}
+}
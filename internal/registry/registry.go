@@ -0,0 +1,163 @@
+// Package registry is a client for the Terraform module registry protocol
+// served by a registry's modules.v1 service.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+
+	rtversion "github.com/registry-tools/rt-cli/version"
+)
+
+// ErrModuleNotFound is returned when the registry has no versions of a module.
+var ErrModuleNotFound = errors.New("module not found")
+
+// Client makes requests to the module registry protocol.
+type Client struct {
+	// BaseURL is the modules.v1 service URL advertised by the registry.
+	BaseURL *url.URL
+	Token   string
+
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+type versionsResponse struct {
+	Modules []struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	} `json:"modules"`
+}
+
+// request requests a path relative to the base URL. The caller must close
+// the response body.
+func (c *Client) request(ctx context.Context, path ...string) (*http.Response, error) {
+	endpoint := c.BaseURL.JoinPath(path...)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "rt-cli/"+rtversion.Version)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	log.Printf("[DEBUG] GET %s", endpoint)
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		res.Body.Close()
+		return nil, ErrModuleNotFound
+	case res.StatusCode >= 300:
+		res.Body.Close()
+		return nil, fmt.Errorf("registry returned %s for %s", res.Status, endpoint)
+	}
+
+	return res, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// get requests a path relative to the base URL and decodes the JSON response
+// into out.
+func (c *Client) get(ctx context.Context, out any, path ...string) error {
+	res, err := c.request(ctx, path...)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// DownloadSource returns the source address that a module version is
+// downloaded from, as given by the X-Terraform-Get header. It may be any
+// address understood by Terraform, Ex: an https URL of an archive or a
+// "git::" address. Relative URLs are resolved against the download endpoint.
+func (c *Client) DownloadSource(ctx context.Context, namespace, name, system, version string) (string, error) {
+	res, err := c.request(ctx, namespace, name, system, version, "download")
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+
+	source := res.Header.Get("X-Terraform-Get")
+	if source == "" {
+		return "", fmt.Errorf("registry did not return a download location for %s/%s/%s %s", namespace, name, system, version)
+	}
+
+	// Addresses with a getter prefix or a scheme are absolute
+	if strings.Contains(source, "::") || strings.Contains(source, "://") {
+		return source, nil
+	}
+
+	ref, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid download location %q: %w", source, err)
+	}
+	return res.Request.URL.ResolveReference(ref).String(), nil
+}
+
+// ModuleVersions returns the available versions of a module, sorted from
+// oldest to newest. Versions that are not valid semantic versions are
+// skipped.
+func (c *Client) ModuleVersions(ctx context.Context, namespace, name, system string) (version.Collection, error) {
+	var res versionsResponse
+	if err := c.get(ctx, &res, namespace, name, system, "versions"); err != nil {
+		return nil, err
+	}
+
+	if len(res.Modules) == 0 {
+		return nil, ErrModuleNotFound
+	}
+
+	var versions version.Collection
+	for _, v := range res.Modules[0].Versions {
+		parsed, err := version.NewSemver(v.Version)
+		if err != nil {
+			log.Printf("[WARN] Ignoring invalid version %q", v.Version)
+			continue
+		}
+		versions = append(versions, parsed)
+	}
+
+	sort.Sort(versions)
+	return versions, nil
+}
+
+// Latest returns the newest version that is not a pre-release, or nil if
+// there is none.
+func Latest(versions version.Collection) *version.Version {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Prerelease() == "" {
+			return versions[i]
+		}
+	}
+	return nil
+}
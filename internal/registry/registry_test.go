@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func newTestClient(t *testing.T, mux *http.ServeMux) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	baseURL, err := url.Parse(server.URL + "/v1/modules/")
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %s", err)
+	}
+
+	return &Client{BaseURL: baseURL, Token: "test-token"}
+}
+
+func TestModuleVersions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/modules/platform/vpc/aws/versions", func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer test-token" {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = res.Write([]byte(`{"modules":[{"versions":[{"version":"1.10.0"},{"version":"2.0.0-beta.1"},{"version":"1.2.0"},{"version":"bogus"}]}]}`))
+	})
+
+	client := newTestClient(t, mux)
+
+	versions, err := client.ModuleVersions(context.Background(), "platform", "vpc", "aws")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	if len(versions) != 3 || versions[0].String() != "1.2.0" || versions[2].String() != "2.0.0-beta.1" {
+		t.Errorf("unexpected versions %v", versions)
+	}
+
+	if latest := Latest(versions); latest == nil || latest.String() != "1.10.0" {
+		t.Errorf("expected latest version 1.10.0, got %v", latest)
+	}
+
+	if _, err := client.ModuleVersions(context.Background(), "platform", "dns", "aws"); !errors.Is(err, ErrModuleNotFound) {
+		t.Errorf("expected ErrModuleNotFound, got %v", err)
+	}
+}
+
+func TestDownloadSource(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/modules/platform/vpc/aws/1.0.0/download", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Terraform-Get", "../../archives/vpc-1.0.0.tar.gz")
+		res.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /v1/modules/platform/vpc/aws/2.0.0/download", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Terraform-Get", "git::https://example.com/vpc.git?ref=v2.0.0")
+		res.WriteHeader(http.StatusNoContent)
+	})
+
+	client := newTestClient(t, mux)
+	ctx := context.Background()
+
+	source, err := client.DownloadSource(ctx, "platform", "vpc", "aws", "1.0.0")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	expected := client.BaseURL.JoinPath("platform", "vpc", "archives", "vpc-1.0.0.tar.gz").String()
+	if source != expected {
+		t.Errorf("expected relative location to resolve to %q, got %q", expected, source)
+	}
+
+	source, err = client.DownloadSource(ctx, "platform", "vpc", "aws", "2.0.0")
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if source != "git::https://example.com/vpc.git?ref=v2.0.0" {
+		t.Errorf("unexpected source %q", source)
+	}
+}
@@ -0,0 +1,18 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    random = "~> 3.0"
+  }
+}
+
+module "nested" {
+  source = "./modules/nested"
+}
+
+module "labels" {
+  source  = "registrytools.cloud/platform/labels/null"
+  version = ">= 1.2.0"
+}
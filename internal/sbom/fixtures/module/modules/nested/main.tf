@@ -0,0 +1,12 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 5.1"
+    }
+  }
+}
+
+module "network" {
+  source = "git::https://example.com/network.git?ref=v1.0.0"
+}
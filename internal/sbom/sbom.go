@@ -0,0 +1,376 @@
+// Package sbom lists the providers and modules that a module depends on, as
+// a CycloneDX software bill of materials.
+package sbom
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	svchost "github.com/hashicorp/terraform-svchost"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/version"
+)
+
+const (
+	// AttachmentName and MediaType describe the document when it is attached
+	// to a module version.
+	AttachmentName = "sbom.cdx.json"
+	MediaType      = "application/vnd.cyclonedx+json"
+
+	// defaultProviderHost is the registry of providers whose source has no
+	// hostname, like Terraform.
+	defaultProviderHost = "registry.terraform.io"
+)
+
+// Kind is the kind of a dependency.
+type Kind string
+
+const (
+	KindProvider Kind = "provider"
+	KindModule   Kind = "module"
+)
+
+// Dependency is a provider or module that a module depends on.
+type Dependency struct {
+	Kind Kind
+	// Source is the provider source, Ex: "registry.terraform.io/hashicorp/aws",
+	// or the module source address.
+	Source string
+	// Constraints are the version constraints of every reference to the
+	// dependency, Ex: "~> 5.0".
+	Constraints []string
+	// Files are the files that reference the dependency.
+	Files []string
+}
+
+var (
+	rootSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "terraform"},
+			{Type: "module", LabelNames: []string{"name"}},
+		},
+	}
+	terraformSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "required_providers"}},
+	}
+	moduleSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "source"}, {Name: "version"}},
+	}
+)
+
+// ScanArchive returns the dependencies declared by the configuration files
+// in a module archive, including nested modules, sorted by kind and source.
+// Modules called by relative path are part of the archive and are not listed.
+func ScanArchive(path string) ([]Dependency, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a gzip file: %w", path, err)
+	}
+	defer gz.Close()
+
+	s := &scanner{parser: hclparse.NewParser(), deps: map[string]*Dependency{}}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid tar archive: %w", path, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !strings.HasSuffix(header.Name, ".tf") && !strings.HasSuffix(header.Name, ".tf.json") {
+			continue
+		}
+
+		src, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.scanFile(header.Name, src); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.dependencies(), nil
+}
+
+type scanner struct {
+	parser *hclparse.Parser
+	deps   map[string]*Dependency
+}
+
+func (s *scanner) add(kind Kind, source, constraint, file string) {
+	key := string(kind) + " " + source
+	dep, ok := s.deps[key]
+	if !ok {
+		dep = &Dependency{Kind: kind, Source: source}
+		s.deps[key] = dep
+	}
+
+	if constraint != "" && !contains(dep.Constraints, constraint) {
+		dep.Constraints = append(dep.Constraints, constraint)
+	}
+	if !contains(dep.Files, file) {
+		dep.Files = append(dep.Files, file)
+	}
+}
+
+func (s *scanner) scanFile(name string, src []byte) error {
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(name, ".json") {
+		file, diags = s.parser.ParseJSON(src, name)
+	} else {
+		file, diags = s.parser.ParseHCL(src, name)
+	}
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", name, diags.Error())
+	}
+
+	content, _, diags := file.Body.PartialContent(rootSchema)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", name, diags.Error())
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "terraform":
+			if err := s.scanTerraformBlock(name, block); err != nil {
+				return err
+			}
+		case "module":
+			attrs, _, diags := block.Body.PartialContent(moduleSchema)
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to parse %s: %s", name, diags.Error())
+			}
+
+			source := stringValue(attrs.Attributes["source"])
+			if source == "" || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+				continue
+			}
+			s.add(KindModule, source, stringValue(attrs.Attributes["version"]), name)
+		}
+	}
+
+	return nil
+}
+
+func (s *scanner) scanTerraformBlock(name string, block *hcl.Block) error {
+	content, _, diags := block.Body.PartialContent(terraformSchema)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", name, diags.Error())
+	}
+
+	for _, providers := range content.Blocks {
+		attrs, diags := providers.Body.JustAttributes()
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to parse %s: %s", name, diags.Error())
+		}
+
+		for localName, attr := range attrs {
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				continue
+			}
+
+			source, constraint := "", ""
+			switch {
+			case value.Type() == cty.String:
+				// Legacy syntax: aws = "~> 5.0"
+				constraint = value.AsString()
+			case value.Type().IsObjectType():
+				source = objectString(value, "source")
+				constraint = objectString(value, "version")
+			default:
+				continue
+			}
+
+			s.add(KindProvider, providerSource(localName, source), constraint, name)
+		}
+	}
+
+	return nil
+}
+
+func (s *scanner) dependencies() []Dependency {
+	result := make([]Dependency, 0, len(s.deps))
+	for _, dep := range s.deps {
+		sort.Strings(dep.Constraints)
+		sort.Strings(dep.Files)
+		result = append(result, *dep)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind == KindProvider
+		}
+		return result[i].Source < result[j].Source
+	})
+	return result
+}
+
+// providerSource returns the fully qualified source of a provider, Ex:
+// "registry.terraform.io/hashicorp/aws" for "hashicorp/aws", or for a
+// provider named "aws" without a source.
+func providerSource(localName, source string) string {
+	if source == "" {
+		source = "hashicorp/" + localName
+	}
+	if strings.Count(source, "/") == 1 {
+		source = defaultProviderHost + "/" + source
+	}
+	return strings.ToLower(source)
+}
+
+func stringValue(attr *hcl.Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+		return ""
+	}
+	return value.AsString()
+}
+
+func objectString(value cty.Value, name string) string {
+	if !value.Type().HasAttribute(name) {
+		return ""
+	}
+	attr := value.GetAttr(name)
+	if attr.IsNull() || !attr.IsKnown() || attr.Type() != cty.String {
+		return ""
+	}
+	return attr.AsString()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Document is a CycloneDX BOM.
+type Document struct {
+	BOMFormat    string      `json:"bomFormat"`
+	SpecVersion  string      `json:"specVersion"`
+	Version      int         `json:"version"`
+	Metadata     Metadata    `json:"metadata"`
+	Components   []Component `json:"components"`
+	Dependencies []DependsOn `json:"dependencies"`
+}
+
+type Metadata struct {
+	Timestamp string    `json:"timestamp,omitempty"`
+	Tools     Tools     `json:"tools"`
+	Component Component `json:"component"`
+}
+
+type Tools struct {
+	Components []Component `json:"components"`
+}
+
+type Component struct {
+	Type       string     `json:"type"`
+	BOMRef     string     `json:"bom-ref,omitempty"`
+	Name       string     `json:"name"`
+	Version    string     `json:"version,omitempty"`
+	Properties []Property `json:"properties,omitempty"`
+}
+
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type DependsOn struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// New returns the CycloneDX document listing the dependencies of the module
+// version. Version constraints are recorded as "rt:constraint" properties,
+// because CycloneDX versions must be exact.
+func New(host svchost.Hostname, mod module.Module, deps []Dependency, timestamp time.Time) Document {
+	root := Component{
+		Type:    "library",
+		BOMRef:  fmt.Sprintf("%s@%s", mod.Source(host), mod.Version),
+		Name:    mod.Source(host),
+		Version: mod.Version,
+	}
+
+	doc := Document{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: Metadata{
+			Tools: Tools{Components: []Component{{
+				Type:    "application",
+				Name:    "rt-cli",
+				Version: version.Version,
+			}}},
+			Component: root,
+		},
+		Components:   []Component{},
+		Dependencies: []DependsOn{{Ref: root.BOMRef, DependsOn: []string{}}},
+	}
+	if !timestamp.IsZero() {
+		doc.Metadata.Timestamp = timestamp.UTC().Format(time.RFC3339)
+	}
+
+	for _, dep := range deps {
+		component := Component{
+			Type:   "library",
+			BOMRef: fmt.Sprintf("%s:%s", dep.Kind, dep.Source),
+			Name:   dep.Source,
+			Properties: []Property{
+				{Name: "rt:kind", Value: string(dep.Kind)},
+			},
+		}
+		for _, constraint := range dep.Constraints {
+			component.Properties = append(component.Properties, Property{Name: "rt:constraint", Value: constraint})
+		}
+		for _, file := range dep.Files {
+			component.Properties = append(component.Properties, Property{Name: "rt:file", Value: file})
+		}
+
+		doc.Components = append(doc.Components, component)
+		doc.Dependencies[0].DependsOn = append(doc.Dependencies[0].DependsOn, component.BOMRef)
+	}
+
+	return doc
+}
+
+// JSON returns the document as indented JSON.
+func (d Document) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SBOM: %w", err)
+	}
+	return data, nil
+}
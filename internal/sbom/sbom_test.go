@@ -0,0 +1,70 @@
+package sbom
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	svchost "github.com/hashicorp/terraform-svchost"
+
+	"github.com/registry-tools/rt-cli/internal/module"
+	"github.com/registry-tools/rt-cli/internal/publish"
+)
+
+func TestScanArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "module.tar.gz")
+	if _, err := publish.PackToFile("./fixtures/module", path, publish.PackOptions{}); err != nil {
+		t.Fatalf("Failed to pack directory: %s", err)
+	}
+
+	deps, err := ScanArchive(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := []Dependency{
+		{Kind: KindProvider, Source: "example.com/acme/tls", Constraints: []string{"1.0.0"}, Files: []string{"versions.tf.json"}},
+		{Kind: KindProvider, Source: "registry.terraform.io/hashicorp/aws", Constraints: []string{">= 5.1", "~> 5.0"}, Files: []string{"main.tf", "modules/nested/main.tf"}},
+		{Kind: KindProvider, Source: "registry.terraform.io/hashicorp/random", Constraints: []string{"~> 3.0"}, Files: []string{"main.tf"}},
+		{Kind: KindModule, Source: "git::https://example.com/network.git?ref=v1.0.0", Files: []string{"modules/nested/main.tf"}},
+		{Kind: KindModule, Source: "registrytools.cloud/platform/labels/null", Constraints: []string{">= 1.2.0"}, Files: []string{"main.tf"}},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestNew(t *testing.T) {
+	deps := []Dependency{
+		{Kind: KindProvider, Source: "registry.terraform.io/hashicorp/aws", Constraints: []string{"~> 5.0"}, Files: []string{"main.tf"}},
+	}
+	mod := module.Module{Namespace: "platform", Name: "vpc", System: "aws", Version: "1.2.3"}
+
+	doc := New(svchost.Hostname("registrytools.cloud"), mod, deps, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	if doc.BOMFormat != "CycloneDX" || doc.Metadata.Timestamp != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected document %+v", doc)
+	}
+	if doc.Metadata.Component.BOMRef != "registrytools.cloud/platform/vpc/aws@1.2.3" {
+		t.Errorf("unexpected component %+v", doc.Metadata.Component)
+	}
+
+	component := doc.Components[0]
+	expectedProperties := []Property{
+		{Name: "rt:kind", Value: "provider"},
+		{Name: "rt:constraint", Value: "~> 5.0"},
+		{Name: "rt:file", Value: "main.tf"},
+	}
+	if component.Name != "registry.terraform.io/hashicorp/aws" || !reflect.DeepEqual(component.Properties, expectedProperties) {
+		t.Errorf("unexpected component %+v", component)
+	}
+
+	if !reflect.DeepEqual(doc.Dependencies, []DependsOn{{Ref: "registrytools.cloud/platform/vpc/aws@1.2.3", DependsOn: []string{"provider:registry.terraform.io/hashicorp/aws"}}}) {
+		t.Errorf("unexpected dependencies %+v", doc.Dependencies)
+	}
+
+	if _, err := doc.JSON(); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package scaffold creates the directory of a new module from a set of
+// templates.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateSuffix is removed from the name of a template file when it is
+// rendered. Files without it are rendered too.
+const TemplateSuffix = ".tmpl"
+
+//go:embed all:templates
+var defaultTemplates embed.FS
+
+// Data is available to templates, Ex: "{{ .Name }}".
+type Data struct {
+	Name      string
+	System    string
+	Namespace string
+	// Host is the hostname of the registry, and Source is the module's
+	// source address in it.
+	Host   string
+	Source string
+}
+
+// Directory returns the conventional directory name of the module,
+// Ex: "terraform-aws-vpc".
+func (d Data) Directory() string {
+	return fmt.Sprintf("terraform-%s-%s", d.System, d.Name)
+}
+
+// DefaultTemplates returns the built-in templates: a module with variables,
+// outputs, version constraints, a README, an example, a .terraformignore, an
+// rt.yaml and a GitHub Actions workflow that publishes tags.
+func DefaultTemplates() fs.FS {
+	templates, err := fs.Sub(defaultTemplates, "templates")
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// Render executes every file in templates with data, and writes the results
+// to dir, which must not exist or be empty. It returns the paths of the
+// files it created, relative to dir.
+func Render(templates fs.FS, dir string, data Data) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("%s already exists and is not empty", dir)
+	}
+
+	type file struct {
+		name    string
+		content []byte
+	}
+	var files []file
+
+	// Render everything before writing, so that a broken template does not
+	// leave a partial module behind
+	err = fs.WalkDir(templates, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		src, err := fs.ReadFile(templates, p)
+		if err != nil {
+			return err
+		}
+
+		t, err := template.New(p).Option("missingkey=error").Parse(string(src))
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+
+		var b bytes.Buffer
+		if err := t.Execute(&b, data); err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+
+		files = append(files, file{name: strings.TrimSuffix(p, TemplateSuffix), content: b.Bytes()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no templates found")
+	}
+
+	created := make([]string, 0, len(files))
+	for _, f := range files {
+		target := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, f.content, 0644); err != nil {
+			return nil, err
+		}
+		created = append(created, path.Clean(f.name))
+	}
+	return created, nil
+}
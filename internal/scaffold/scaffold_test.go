@@ -0,0 +1,132 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/registry-tools/rt-cli/internal/lint"
+	"github.com/registry-tools/rt-cli/internal/moduleconfig"
+)
+
+var data = Data{
+	Name:      "vpc",
+	System:    "aws",
+	Namespace: "platform",
+	Host:      "registrytools.cloud",
+	Source:    "registrytools.cloud/platform/vpc/aws",
+}
+
+func TestRenderDefaultTemplates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), data.Directory())
+
+	created, err := Render(DefaultTemplates(), dir, data)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	sort.Strings(created)
+	expected := []string{
+		".github/workflows/publish.yml",
+		".terraformignore",
+		"README.md",
+		"examples/basic/main.tf",
+		"main.tf",
+		"outputs.tf",
+		"rt.yaml",
+		"variables.tf",
+		"versions.tf",
+	}
+	if strings.Join(created, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected files %v, got %v", expected, created)
+	}
+
+	workflow, err := os.ReadFile(filepath.Join(dir, ".github", "workflows", "publish.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`namespace: "platform"`, `system: "aws"`, "version: ${{ steps.version.outputs.version }}"} {
+		if !strings.Contains(string(workflow), s) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", s, workflow)
+		}
+	}
+
+	readme, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(readme), `source  = "registrytools.cloud/platform/vpc/aws"`) {
+		t.Errorf("expected README to contain the source, got:\n%s", readme)
+	}
+
+	config, err := moduleconfig.Load(dir)
+	if err != nil {
+		t.Fatalf("expected a valid rt.yaml, got %s", err)
+	}
+	findings, err := lint.Lint(dir, config.Lint)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected the scaffolded module to pass lint, got %v", findings)
+	}
+}
+
+func TestRenderCustomTemplates(t *testing.T) {
+	templates := fstest.MapFS{
+		"main.tf.tmpl":  {Data: []byte(`# {{ .Name }} for {{ .System }}`)},
+		"LICENSE":       {Data: []byte("MIT")},
+		".git/HEAD":     {Data: []byte("ref: refs/heads/main")},
+		"docs/notes.md": {Data: []byte("{{ .Namespace }}")},
+	}
+	dir := t.TempDir()
+
+	created, err := Render(templates, dir, data)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	sort.Strings(created)
+	if strings.Join(created, ",") != "LICENSE,docs/notes.md,main.tf" {
+		t.Errorf("unexpected files %v", created)
+	}
+
+	main, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(main) != "# vpc for aws" {
+		t.Errorf("unexpected main.tf %q", main)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	t.Run("not empty", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "main.tf"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Render(DefaultTemplates(), dir, data); err == nil {
+			t.Error("expected an error for a directory that is not empty")
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "module")
+		templates := fstest.MapFS{"main.tf": {Data: []byte("{{ .Region }}")}}
+		if _, err := Render(templates, dir, data); err == nil {
+			t.Error("expected an error for an unknown field")
+		}
+		if _, err := os.Stat(dir); err == nil {
+			t.Error("expected no files to be written")
+		}
+	})
+
+	t.Run("no templates", func(t *testing.T) {
+		if _, err := Render(fstest.MapFS{}, t.TempDir(), data); err == nil {
+			t.Error("expected an error for an empty template directory")
+		}
+	})
+}
@@ -0,0 +1,344 @@
+// Package server implements a local registry for offline testing. It serves
+// the Terraform module registry protocol and the subset of the Registry Tools
+// API used to publish modules, storing archives in a directory on disk.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Server is an http.Handler for a local registry.
+type Server struct {
+	// Dir is the directory that archives and metadata are stored in.
+	Dir string
+
+	// Token, when set, must be presented as a bearer token by every request
+	// other than service discovery. When empty, any request is allowed.
+	Token string
+
+	store *store
+	mux   *http.ServeMux
+}
+
+// New returns a server that stores modules in dir.
+func New(dir, token string) *Server {
+	s := &Server{
+		Dir:   dir,
+		Token: token,
+		store: &store{dir: dir},
+		mux:   http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /.well-known/terraform.json", s.handleDiscovery)
+
+	s.mux.HandleFunc("GET /v1/modules/{namespace}/{name}/{system}/versions", s.authenticated(s.handleVersions))
+	s.mux.HandleFunc("GET /v1/modules/{namespace}/{name}/{system}/{version}/download", s.authenticated(s.handleDownload))
+	s.mux.HandleFunc("GET /archives/{namespace}/{name}/{system}/{file}", s.authenticated(s.handleArchive))
+
+	s.mux.HandleFunc("GET /api/whoami", s.authenticated(s.handleWhoami))
+	s.mux.HandleFunc("POST /api/archives", s.authenticated(s.handleCreateArchive))
+	// Like a presigned URL, the upload URL is authorized by the unguessable
+	// archive ID rather than the bearer token
+	s.mux.HandleFunc("PUT /api/archives/{id}/blob", s.handleUploadArchive)
+	s.mux.HandleFunc("GET /api/terraform-module-versions", s.authenticated(s.handleListModuleVersions))
+	s.mux.HandleFunc("POST /api/terraform-module-versions", s.authenticated(s.handleCreateModuleVersion))
+	s.mux.HandleFunc("PATCH /api/terraform-module-versions/{id}", s.authenticated(s.handleYankModuleVersion))
+	s.mux.HandleFunc("POST /api/terraform-module-versions/{id}/attachments", s.authenticated(s.handleCreateAttachment))
+	s.mux.HandleFunc("GET /api/terraform-module-versions/{id}/attachments/{name}", s.authenticated(s.handleAttachment))
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[DEBUG] %s %s", r.Method, r.URL)
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.Token != "" {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "Unauthorized", "a valid bearer token is required")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// baseURL returns the URL that the client used to reach the server.
+func baseURL(r *http.Request) *url.URL {
+	scheme := "https"
+	if r.TLS == nil {
+		scheme = "http"
+	}
+	return &url.URL{Scheme: scheme, Host: r.Host, Path: "/"}
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[ERROR] Failed to write response: %s", err)
+	}
+}
+
+func writeData(w http.ResponseWriter, status int, data any) {
+	writeJSONResponse(w, status, map[string]any{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, title, detail string) {
+	writeJSONResponse(w, status, map[string]any{
+		"errors": []map[string]string{{
+			"status": fmt.Sprint(status),
+			"title":  title,
+			"detail": detail,
+		}},
+	})
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotFound):
+		writeError(w, http.StatusNotFound, "Not Found", "")
+	case errors.Is(err, errConflict):
+		writeError(w, http.StatusConflict, "Conflict", "this module version has already been published")
+	default:
+		log.Printf("[ERROR] %s", err)
+		writeError(w, http.StatusInternalServerError, "Internal Server Error", err.Error())
+	}
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, map[string]string{
+		"modules.v1": "/v1/modules/",
+		"rt.v1":      "/",
+	})
+}
+
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := s.store.versions(r.PathValue("namespace"), r.PathValue("name"), r.PathValue("system"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if len(versions) == 0 {
+		writeError(w, http.StatusNotFound, "Not Found", "module not found")
+		return
+	}
+
+	type protocolVersion struct {
+		Version string `json:"version"`
+	}
+
+	available := []protocolVersion{}
+	for _, v := range versions {
+		// Yanked versions can still be downloaded, but are not offered to
+		// version constraints
+		if !v.Yanked {
+			available = append(available, protocolVersion{Version: v.Version})
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]any{
+		"modules": []map[string]any{{"versions": available}},
+	})
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	namespace, name, system, v := r.PathValue("namespace"), r.PathValue("name"), r.PathValue("system"), r.PathValue("version")
+	if _, err := s.store.archivePath(namespace, name, system, v); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	archive := baseURL(r).JoinPath("archives", namespace, name, system, v+".tar.gz")
+	archive.RawQuery = "archive=tar.gz"
+
+	w.Header().Set("X-Terraform-Get", archive.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	v, ok := strings.CutSuffix(r.PathValue("file"), ".tar.gz")
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "")
+		return
+	}
+
+	path, err := s.store.archivePath(r.PathValue("namespace"), r.PathValue("name"), r.PathValue("system"), v)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	http.ServeFile(w, r, path)
+}
+
+func (s *Server) handleWhoami(w http.ResponseWriter, r *http.Request) {
+	writeData(w, http.StatusOK, map[string]string{
+		"id":   "local",
+		"type": "service-account",
+		"name": "rt serve",
+	})
+}
+
+func (s *Server) handleCreateArchive(w http.ResponseWriter, r *http.Request) {
+	id, err := s.store.createArchive()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, map[string]any{
+		"data": map[string]string{
+			"signed-id": id,
+			"filename":  id + ".tar.gz",
+		},
+		"meta": map[string]any{
+			"upload-url": baseURL(r).JoinPath("api", "archives", id, "blob").String(),
+			"headers":    map[string]string{"Content-Type": "application/gzip"},
+		},
+	})
+}
+
+func (s *Server) handleUploadArchive(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.writeArchive(r.PathValue("id"), r.Body); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListModuleVersions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	versions, err := s.store.versions(query.Get("filter[namespace]"), query.Get("filter[name]"), query.Get("filter[system]"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+
+	if versions == nil {
+		versions = []ModuleVersion{}
+	}
+	writeData(w, http.StatusOK, versions)
+}
+
+// createModuleVersionRequest accepts attributes either directly in "data" or
+// in "data.attributes".
+type createModuleVersionRequest struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	System    string `json:"system"`
+	Version   string `json:"version"`
+	ArchiveID string `json:"archive-id"`
+
+	SourceRepository string `json:"source-repository"`
+	SourceCommit     string `json:"source-commit"`
+	ArchiveSHA256    string `json:"archive-sha256"`
+
+	Attributes *createModuleVersionRequest `json:"attributes"`
+}
+
+func (s *Server) handleCreateModuleVersion(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Data createModuleVersionRequest `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+
+	req := body.Data
+	if req.Attributes != nil {
+		req = *req.Attributes
+	}
+
+	v, err := s.store.createVersion(ModuleVersion{
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		System:    req.System,
+		Version:   req.Version,
+
+		SourceRepository: req.SourceRepository,
+		SourceCommit:     req.SourceCommit,
+		ArchiveSHA256:    req.ArchiveSHA256,
+	}, req.ArchiveID)
+	if err != nil {
+		if errors.Is(err, errConflict) {
+			writeStoreError(w, err)
+			return
+		}
+		writeError(w, http.StatusUnprocessableEntity, "Invalid module version", err.Error())
+		return
+	}
+
+	log.Printf("[INFO] Published %s/%s/%s %s", v.Namespace, v.Name, v.System, v.Version)
+	writeData(w, http.StatusCreated, v)
+}
+
+func (s *Server) handleYankModuleVersion(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Data struct {
+			Yanked       bool   `json:"yanked"`
+			YankedReason string `json:"yanked-reason"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+	if !body.Data.Yanked {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid request", "yanked versions cannot be restored")
+		return
+	}
+
+	v, err := s.store.yankVersion(r.PathValue("id"), body.Data.YankedReason)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	log.Printf("[INFO] Yanked %s/%s/%s %s", v.Namespace, v.Name, v.System, v.Version)
+	writeData(w, http.StatusOK, v)
+}
+
+func (s *Server) handleCreateAttachment(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Data Attachment `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+
+	if err := s.store.putAttachment(r.PathValue("id"), body.Data); err != nil {
+		if errors.Is(err, errNotFound) {
+			writeStoreError(w, err)
+			return
+		}
+		writeError(w, http.StatusUnprocessableEntity, "Invalid attachment", err.Error())
+		return
+	}
+
+	log.Printf("[INFO] Attached %s to %s", body.Data.Name, r.PathValue("id"))
+	writeData(w, http.StatusCreated, body.Data)
+}
+
+func (s *Server) handleAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, err := s.store.attachment(r.PathValue("id"), r.PathValue("name"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeData(w, http.StatusOK, attachment)
+}
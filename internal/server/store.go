@@ -0,0 +1,368 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+var (
+	errNotFound = errors.New("not found")
+	errConflict = errors.New("already exists")
+)
+
+// validName matches namespaces, module names, systems and IDs, which are used
+// as path components on disk.
+var validName = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z_.-]*$`)
+
+// ModuleVersion is the metadata stored for a published module version.
+type ModuleVersion struct {
+	ID           string    `json:"id"`
+	Namespace    string    `json:"namespace"`
+	Name         string    `json:"name"`
+	System       string    `json:"system"`
+	Version      string    `json:"version"`
+	Yanked       bool      `json:"yanked"`
+	YankedReason string    `json:"yanked-reason,omitempty"`
+	CreatedAt    time.Time `json:"created-at"`
+
+	SourceRepository string `json:"source-repository,omitempty"`
+	SourceCommit     string `json:"source-commit,omitempty"`
+	ArchiveSHA256    string `json:"archive-sha256,omitempty"`
+}
+
+// Attachment is a file attached to a module version.
+type Attachment struct {
+	Name      string `json:"name"`
+	MediaType string `json:"media-type"`
+	Content   string `json:"content"`
+}
+
+// store keeps archives and module versions on disk:
+//
+//	uploads/<archive-id>.tar.gz                  archives not yet published
+//	modules/<namespace>/<name>/<system>/<version>.tar.gz
+//	modules/<namespace>/<name>/<system>/<version>.json
+//	modules/<namespace>/<name>/<system>/<version>.attachments/<name>.json
+type store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+func (s *store) uploadPath(archiveID string) (string, error) {
+	if !validName.MatchString(archiveID) {
+		return "", errNotFound
+	}
+	return filepath.Join(s.dir, "uploads", archiveID+".tar.gz"), nil
+}
+
+func (s *store) modulePath(namespace, name, system string) (string, error) {
+	for _, part := range []string{namespace, name, system} {
+		if !validName.MatchString(part) {
+			return "", fmt.Errorf("invalid module address component %q", part)
+		}
+	}
+	return filepath.Join(s.dir, "modules", namespace, name, system), nil
+}
+
+// createArchive reserves an ID for an archive that will be uploaded.
+func (s *store) createArchive() (string, error) {
+	if err := os.MkdirAll(filepath.Join(s.dir, "uploads"), 0755); err != nil {
+		return "", err
+	}
+
+	id, err := randomID("arc-")
+	if err != nil {
+		return "", err
+	}
+
+	path, err := s.uploadPath(id)
+	if err != nil {
+		return "", err
+	}
+	return id, os.WriteFile(path, nil, 0644)
+}
+
+// writeArchive stores the content of an uploaded archive. Only archives
+// reserved by createArchive can be written.
+func (s *store) writeArchive(archiveID string, r io.Reader) error {
+	path, err := s.uploadPath(archiveID)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return errNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// createVersion publishes the uploaded archive as a module version.
+func (s *store) createVersion(v ModuleVersion, archiveID string) (*ModuleVersion, error) {
+	if _, err := version.NewSemver(v.Version); err != nil {
+		return nil, fmt.Errorf("invalid version %q: %w", v.Version, err)
+	}
+
+	dir, err := s.modulePath(v.Namespace, v.Name, v.System)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := s.uploadPath(archiveID)
+	if err != nil {
+		return nil, fmt.Errorf("archive %q was not uploaded", archiveID)
+	}
+	if info, err := os.Stat(upload); err != nil || info.Size() == 0 {
+		return nil, fmt.Errorf("archive %q was not uploaded", archiveID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metaPath := filepath.Join(dir, v.Version+".json")
+	if _, err := os.Stat(metaPath); err == nil {
+		return nil, errConflict
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(upload, filepath.Join(dir, v.Version+".tar.gz")); err != nil {
+		return nil, err
+	}
+
+	v.ID, err = randomID("mv-")
+	if err != nil {
+		return nil, err
+	}
+	v.CreatedAt = time.Now().UTC()
+
+	if err := writeJSON(metaPath, v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// versions returns the versions of a module, sorted from oldest to newest.
+func (s *store) versions(namespace, name, system string) ([]ModuleVersion, error) {
+	dir, err := s.modulePath(namespace, name, system)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []ModuleVersion
+	var parsed []*version.Version
+	for _, path := range matches {
+		var v ModuleVersion
+		if err := readJSON(path, &v); err != nil {
+			return nil, err
+		}
+
+		// Versions are validated when they are published, so an invalid one
+		// was written to the directory by hand
+		semver, err := version.NewSemver(v.Version)
+		if err != nil {
+			log.Printf("[WARN] Skipping %s: invalid version %q", path, v.Version)
+			continue
+		}
+		versions = append(versions, v)
+		parsed = append(parsed, semver)
+	}
+
+	sort.Sort(byVersion{versions, parsed})
+
+	return versions, nil
+}
+
+// byVersion sorts module versions by their parsed versions, which are kept
+// in the same order.
+type byVersion struct {
+	versions []ModuleVersion
+	parsed   []*version.Version
+}
+
+func (s byVersion) Len() int           { return len(s.versions) }
+func (s byVersion) Less(i, j int) bool { return s.parsed[i].LessThan(s.parsed[j]) }
+func (s byVersion) Swap(i, j int) {
+	s.versions[i], s.versions[j] = s.versions[j], s.versions[i]
+	s.parsed[i], s.parsed[j] = s.parsed[j], s.parsed[i]
+}
+
+// archivePath returns the path of the archive of a module version.
+func (s *store) archivePath(namespace, name, system, v string) (string, error) {
+	dir, err := s.modulePath(namespace, name, system)
+	if err != nil {
+		return "", errNotFound
+	}
+	if _, err := version.NewSemver(v); err != nil {
+		return "", errNotFound
+	}
+
+	path := filepath.Join(dir, v+".tar.gz")
+	if _, err := os.Stat(path); err != nil {
+		return "", errNotFound
+	}
+	return path, nil
+}
+
+// findVersion returns the metadata file and module version with the
+// specified ID.
+func (s *store) findVersion(id string) (string, *ModuleVersion, error) {
+	var found string
+	var result ModuleVersion
+
+	err := filepath.WalkDir(filepath.Join(s.dir, "modules"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() && strings.HasSuffix(path, ".attachments") {
+			return fs.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		var v ModuleVersion
+		if err := readJSON(path, &v); err != nil {
+			return err
+		}
+		if v.ID == id {
+			found, result = path, v
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if found == "" {
+		return "", nil, errNotFound
+	}
+	return found, &result, nil
+}
+
+// yankVersion marks the module version with the specified ID as yanked.
+func (s *store) yankVersion(id, reason string) (*ModuleVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, v, err := s.findVersion(id)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Yanked = true
+	v.YankedReason = reason
+	if err := writeJSON(path, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// attachmentPath returns the path of an attachment of the module version
+// with the specified ID.
+func (s *store) attachmentPath(id, name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid attachment name %q", name)
+	}
+
+	path, _, err := s.findVersion(id)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(strings.TrimSuffix(path, ".json")+".attachments", name+".json"), nil
+}
+
+// putAttachment attaches a file to the module version with the specified ID,
+// replacing any attachment with the same name.
+func (s *store) putAttachment(id string, attachment Attachment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.attachmentPath(id, attachment.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeJSON(path, attachment)
+}
+
+// attachment returns the named attachment of the module version with the
+// specified ID.
+func (s *store) attachment(id, name string) (*Attachment, error) {
+	path, err := s.attachmentPath(id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment Attachment
+	if err := readJSON(path, &attachment); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
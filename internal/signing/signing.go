@@ -0,0 +1,163 @@
+// Package signing signs module versions and verifies their signatures.
+//
+// A signature is an ed25519 signature over the JSON encoding of a Payload,
+// which names the module version and the SHA-256 digest of its archive, so it
+// cannot be reused for another module or version with the same archive. It is
+// attached to the module version alongside the payload. Keys are PEM encoded,
+// as written by "openssl genpkey -algorithm ed25519": PKCS #8 for private
+// keys and PKIX for public keys.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// AttachmentName and MediaType describe a signature when it is attached
+	// to a module version.
+	AttachmentName = "signature.json"
+	MediaType      = "application/vnd.registry-tools.signature+json"
+)
+
+// ErrInvalidSignature is returned when a signature does not match the
+// module version, archive digest and public key.
+var ErrInvalidSignature = errors.New("signature is not valid for this module version, archive and public key")
+
+// Payload is the statement that is signed.
+type Payload struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	System        string `json:"system"`
+	Version       string `json:"version"`
+	ArchiveSHA256 string `json:"archive-sha256"`
+}
+
+func (p Payload) String() string {
+	return fmt.Sprintf("%s/%s/%s %s with SHA-256 %s", p.Namespace, p.Name, p.System, p.Version, p.ArchiveSHA256)
+}
+
+// message returns the bytes that are signed.
+func (p Payload) message() ([]byte, error) {
+	if p.Namespace == "" || p.Name == "" || p.System == "" || p.Version == "" {
+		return nil, errors.New("the namespace, name, system and version of the module are required")
+	}
+	sum, err := hex.DecodeString(p.ArchiveSHA256)
+	if err != nil || len(sum) != 32 {
+		return nil, fmt.Errorf("invalid SHA-256 digest %q", p.ArchiveSHA256)
+	}
+	return json.Marshal(p)
+}
+
+// Signature is a signed payload, as attached to a module version.
+type Signature struct {
+	Payload Payload `json:"payload"`
+	// Signature is the base64 encoded signature of the JSON encoding of
+	// Payload.
+	Signature string `json:"signature"`
+}
+
+// JSON returns the signature as indented JSON.
+func (s *Signature) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// ParseSignature reads a signature from its JSON encoding.
+func ParseSignature(data []byte) (*Signature, error) {
+	var s Signature
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	return &s, nil
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded %s", path, blockType)
+	}
+	return block.Bytes, nil
+}
+
+// LoadPrivateKey reads a PEM encoded PKCS #8 ed25519 private key.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 private key", path)
+	}
+	return private, nil
+}
+
+// LoadPublicKey reads a PEM encoded PKIX ed25519 public key.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 public key", path)
+	}
+	return public, nil
+}
+
+// Sign signs payload with key.
+func Sign(key ed25519.PrivateKey, payload Payload) (*Signature, error) {
+	message, err := payload.message()
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{
+		Payload:   payload,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, message)),
+	}, nil
+}
+
+// Verify checks that signature was made with the private key of key for
+// expected, the module version and archive digest being verified.
+func Verify(key ed25519.PublicKey, expected Payload, signature *Signature) error {
+	if signature.Payload != expected {
+		return fmt.Errorf("%w: it was made for %s", ErrInvalidSignature, signature.Payload)
+	}
+
+	message, err := expected.message()
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(key, message, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const digest = "0ac7d4dc66752cd1b09dccda1783f547dfd7480dad697595518065f82cb0a9d2"
+
+var payload = Payload{Namespace: "platform", Name: "vpc", System: "aws", Version: "1.0.0", ArchiveSHA256: digest}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeKeys(t *testing.T) (string, string) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(private)
+	publicDER, _ := x509.MarshalPKIXPublicKey(public)
+	return writePEM(t, "PRIVATE KEY", privateDER), writePEM(t, "PUBLIC KEY", publicDER)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	privatePath, publicPath := writeKeys(t)
+	private, err := LoadPrivateKey(privatePath)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	public, err := LoadPublicKey(publicPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	signature, err := Sign(private, payload)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	data, err := signature.JSON()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	parsed, err := ParseSignature(data)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if err := Verify(public, payload, parsed); err != nil {
+		t.Errorf("expected the signature to be valid, got %s", err)
+	}
+
+	// Every field of the payload is covered by the signature
+	others := map[string]Payload{}
+	for field, change := range map[string]func(*Payload){
+		"digest":    func(p *Payload) { p.ArchiveSHA256 = strings.Replace(digest, "0a", "0b", 1) },
+		"namespace": func(p *Payload) { p.Namespace = "other" },
+		"name":      func(p *Payload) { p.Name = "other" },
+		"system":    func(p *Payload) { p.System = "gcp" },
+		"version":   func(p *Payload) { p.Version = "1.0.1" },
+	} {
+		other := payload
+		change(&other)
+		others[field] = other
+	}
+	for field, other := range others {
+		if err := Verify(public, other, parsed); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected a different %s to be rejected, got %v", field, err)
+		}
+
+		// The signature doesn't verify even if the recorded payload is
+		// changed to match
+		tampered := *parsed
+		tampered.Payload = other
+		if err := Verify(public, other, &tampered); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected a tampered %s to be rejected, got %v", field, err)
+		}
+	}
+
+	_, otherPublicPath := writeKeys(t)
+	otherPublic, _ := LoadPublicKey(otherPublicPath)
+	if err := Verify(otherPublic, payload, parsed); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a different key to be rejected, got %v", err)
+	}
+}
+
+func TestLoadKeyErrors(t *testing.T) {
+	privatePath, publicPath := writeKeys(t)
+
+	if _, err := LoadPrivateKey(publicPath); err == nil {
+		t.Error("expected an error loading a public key as a private key")
+	}
+	if _, err := LoadPublicKey(privatePath); err == nil {
+		t.Error("expected an error loading a private key as a public key")
+	}
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaDER, _ := x509.MarshalPKCS8PrivateKey(ecdsaKey)
+	if _, err := LoadPrivateKey(writePEM(t, "PRIVATE KEY", ecdsaDER)); err == nil || !strings.Contains(err.Error(), "not an ed25519") {
+		t.Errorf("expected an error loading an ECDSA key, got %v", err)
+	}
+}
+
+func TestSignInvalidPayload(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+
+	invalid := payload
+	invalid.ArchiveSHA256 = "abc123"
+	if _, err := Sign(private, invalid); err == nil {
+		t.Error("expected an error signing an invalid digest")
+	}
+
+	invalid = payload
+	invalid.Version = ""
+	if _, err := Sign(private, invalid); err == nil {
+		t.Error("expected an error signing without a version")
+	}
+}
@@ -0,0 +1,91 @@
+package summarize
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/registry-tools/rt-cli/internal/publish"
+)
+
+// Stage is the step of publishing that failed.
+type Stage string
+
+const (
+	StageInputs   Stage = "inputs"
+	StageAuth     Stage = "auth"
+	StageValidate Stage = "validate"
+	StagePack     Stage = "pack"
+	StageUpload   Stage = "upload"
+)
+
+var stageHints = map[Stage]string{
+	StageInputs:   "Check that the namespace and version inputs are set, and that the module name can be determined from the repository or the module input.",
+	StageAuth:     "Set REGISTRY_TOOLS_TOKEN, or REGISTRY_TOOLS_CLIENT_ID and REGISTRY_TOOLS_CLIENT_SECRET. On GitHub Actions, grant the workflow the `id-token: write` permission to authenticate without a stored secret.",
+	StageValidate: "Fix the problems listed above in the module source, then run the job again.",
+	StagePack:     "Check that the directory input points at the module source and that every file in it is readable.",
+	StageUpload:   "Check that the namespace exists, that the credentials are allowed to publish to it, and that this version has not already been published.",
+}
+
+var tmplFailureHTML = `
+<h3>Module Publishing Failed</h3>
+
+<p>{{.Message}}</p>
+{{if .Problems}}
+<ul>
+{{range .Problems}}<li>{{if .File}}<code>{{.File}}{{if .Line}}:{{.Line}}{{end}}</code>: {{end}}{{.Summary}}{{if .Detail}}. {{.Detail}}{{end}}</li>
+{{end}}</ul>
+{{end}}
+{{if .Hint}}<p><strong>How to fix:</strong> {{.Hint}}</p>{{end}}
+`
+
+// Failure describes why a module could not be published.
+type Failure struct {
+	Stage    Stage
+	Err      error
+	Problems publish.Problems
+}
+
+func (f Failure) message() string {
+	switch f.Stage {
+	case StageInputs:
+		return fmt.Sprintf("The inputs to the step are invalid: %s", f.Err)
+	case StageAuth:
+		return fmt.Sprintf("Could not authenticate to the registry: %s", f.Err)
+	case StageValidate:
+		if f.Err != nil {
+			return fmt.Sprintf("The module source could not be validated: %s", f.Err)
+		}
+		return "The module source has problems that would prevent it from being used."
+	case StagePack:
+		return fmt.Sprintf("The module source could not be packed into an archive: %s", f.Err)
+	case StageUpload:
+		return fmt.Sprintf("The module could not be published: %s", f.Err)
+	default:
+		return fmt.Sprintf("%s", f.Err)
+	}
+}
+
+func (f Failure) HTML() (string, error) {
+	t, err := template.New("").Parse(tmplFailureHTML)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	data := struct {
+		Message  string
+		Problems publish.Problems
+		Hint     string
+	}{
+		Message:  f.message(),
+		Problems: f.Problems,
+		Hint:     stageHints[f.Stage],
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return b.String(), nil
+}
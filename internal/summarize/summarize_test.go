@@ -1,9 +1,12 @@
 package summarize
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/andreyvit/diff"
+	"github.com/hashicorp/hcl/v2"
 	svchost "github.com/hashicorp/terraform-svchost"
 	"github.com/registry-tools/rt-cli/internal/publish"
 )
@@ -63,3 +66,99 @@ Example Usage:
 		t.Errorf("unexpected summary HTML:\n%v", diff.LineDiff(output, expected))
 	}
 }
+
+func TestSummaryOutputs(t *testing.T) {
+	mod := publish.ModuleVersion{
+		ID:        "mv-123",
+		Name:      "computer",
+		System:    "aws",
+		Version:   "1.0.0",
+		Namespace: "spacepioneer",
+	}
+
+	summary := NewSummary(1024, svchost.Hostname("registrytools.cloud"), &mod)
+	summary.SHA256 = "abc123"
+
+	outputs, err := summary.Outputs()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	actual := map[string]string{}
+	for _, output := range outputs {
+		actual[output.Name] = output.Value
+	}
+
+	expected := map[string]string{
+		"source":            "registrytools.cloud/spacepioneer/computer/aws",
+		"version":           "1.0.0",
+		"module-version-id": "mv-123",
+		"namespace":         "spacepioneer",
+		"name":              "computer",
+		"system":            "aws",
+		"archive-sha256":    "abc123",
+		"archive-size":      "1024",
+		"registry-url":      "https://registrytools.cloud/modules/spacepioneer/computer/aws/1.0.0",
+		"source-commit":     "",
+		"summary":           `{"module-version-id":"mv-123","namespace":"spacepioneer","name":"computer","system":"aws","version":"1.0.0","source":"registrytools.cloud/spacepioneer/computer/aws","registry-url":"https://registrytools.cloud/modules/spacepioneer/computer/aws/1.0.0","archive-sha256":"abc123","archive-size":1024}`,
+	}
+
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected output %q to be %q, got %q", name, value, actual[name])
+		}
+	}
+	if len(actual) != len(expected) {
+		t.Errorf("expected %d outputs, got %d", len(expected), len(actual))
+	}
+
+	html, err := summary.HTML()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !strings.Contains(html, "<p>SHA-256: <code>abc123</code></p>") {
+		t.Errorf("expected the summary to include the archive digest, got %q", html)
+	}
+	if !strings.Contains(summary.CLI(), "Archive SHA-256: abc123") {
+		t.Errorf("expected the CLI summary to include the archive digest, got %q", summary.CLI())
+	}
+}
+
+func TestFailure(t *testing.T) {
+	failure := Failure{
+		Stage: StageValidate,
+		Problems: publish.Problems{
+			{Severity: hcl.DiagError, Summary: "Unclosed configuration block", File: "main.tf", Line: 3},
+		},
+	}
+
+	output, err := failure.HTML()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := `
+<h3>Module Publishing Failed</h3>
+
+<p>The module source has problems that would prevent it from being used.</p>
+
+<ul>
+<li><code>main.tf:3</code>: Unclosed configuration block</li>
+</ul>
+
+<p><strong>How to fix:</strong> Fix the problems listed above in the module source, then run the job again.</p>
+`
+
+	if output != expected {
+		t.Errorf("unexpected failure HTML:\n%v", diff.LineDiff(output, expected))
+	}
+
+	failure = Failure{Stage: StageUpload, Err: errors.New("namespace <x> does not exist")}
+	output, err = failure.HTML()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !strings.Contains(output, "namespace &lt;x&gt; does not exist") {
+		t.Errorf("expected the error to be escaped, got %q", output)
+	}
+}
@@ -2,8 +2,10 @@ package summarize
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
@@ -36,7 +38,9 @@ Example Usage:
 <code>{{.TFTokenExample}}</code>
 
 <p>The size of this module, gzipped, was {{.SizeHuman}}</p>
-
+{{if .SHA256}}
+<p>SHA-256: <code>{{.SHA256}}</code></p>
+{{end}}
 {{if gt .Size 1000000}}
 <p>This seems to be extraordinarily large for an IaC module. Use a .terraformignore file to exclude files that aren't needed by the module.</p>
 {{end}}
@@ -45,16 +49,22 @@ Example Usage:
 type templateData struct {
 	Size             int64
 	SizeHuman        string
+	SHA256           string
 	TerraformExample string
 	TFTokenExample   string
 	ProvisionURL     string
 }
 
 type Summary struct {
+	// Size is the size of the gzipped archive in bytes.
 	Size      int64
 	Namespace string
 	Module    *publish.ModuleVersion
 	Host      svchost.Hostname
+	// SHA256 is the hex encoded digest of the published archive, if known.
+	SHA256 string
+	// SourceCommit is the git commit the module was published from, if known.
+	SourceCommit string
 }
 
 func NewSummary(size int64, host svchost.Hostname, mod *publish.ModuleVersion) Summary {
@@ -79,12 +89,86 @@ func (s Summary) getTemplateData() templateData {
 	return templateData{
 		Size:             s.Size,
 		SizeHuman:        HumanizeBytes(s.Size),
+		SHA256:           s.SHA256,
 		TerraformExample: mod.ToTerraformExample(s.Host),
 		TFTokenExample:   fmt.Sprintf("TF_TOKEN_%s=<token>", hostEscaped),
 		ProvisionURL:     fmt.Sprintf("https://%s/provision", s.Host.String()),
 	}
 }
 
+// RegistryURL returns the URL of the published module version in the
+// registry.
+func (s Summary) RegistryURL() string {
+	mod := s.module()
+	return fmt.Sprintf("https://%s/modules/%s/%s/%s/%s", s.Host.String(), mod.Namespace, mod.Name, mod.System, mod.Version)
+}
+
+// Output is a named value describing the published module, for use by later
+// steps in a pipeline.
+type Output struct {
+	Name  string
+	Value string
+}
+
+type jsonSummary struct {
+	ModuleVersionID string `json:"module-version-id"`
+	Namespace       string `json:"namespace"`
+	Name            string `json:"name"`
+	System          string `json:"system"`
+	Version         string `json:"version"`
+	Source          string `json:"source"`
+	RegistryURL     string `json:"registry-url"`
+	ArchiveSHA256   string `json:"archive-sha256,omitempty"`
+	ArchiveSize     int64  `json:"archive-size"`
+	SourceCommit    string `json:"source-commit,omitempty"`
+}
+
+// JSON returns the summary as a single line JSON object.
+func (s Summary) JSON() (string, error) {
+	mod := s.module()
+	data, err := json.Marshal(jsonSummary{
+		ModuleVersionID: s.Module.ID,
+		Namespace:       mod.Namespace,
+		Name:            mod.Name,
+		System:          mod.System,
+		Version:         mod.Version,
+		Source:          mod.Source(s.Host),
+		RegistryURL:     s.RegistryURL(),
+		ArchiveSHA256:   s.SHA256,
+		ArchiveSize:     s.Size,
+		SourceCommit:    s.SourceCommit,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// Outputs returns the values describing the published module, including the
+// whole summary as JSON in the "summary" output.
+func (s Summary) Outputs() ([]Output, error) {
+	summary, err := s.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	mod := s.module()
+	return []Output{
+		{"source", mod.Source(s.Host)},
+		{"version", mod.Version},
+		{"module-version-id", s.Module.ID},
+		{"namespace", mod.Namespace},
+		{"name", mod.Name},
+		{"system", mod.System},
+		{"archive-sha256", s.SHA256},
+		{"archive-size", strconv.FormatInt(s.Size, 10)},
+		{"registry-url", s.RegistryURL()},
+		{"source-commit", s.SourceCommit},
+		{"summary", summary},
+	}, nil
+}
+
 func (s Summary) HTML() (string, error) {
 	data := s.getTemplateData()
 	t, err := template.New("").Parse(tmplHTML)
@@ -118,6 +202,10 @@ func (s Summary) CLI() string {
 	data := s.getTemplateData()
 	result.WriteString(indent(2, codeSample.Sprint(data.TerraformExample)))
 
+	if data.SHA256 != "" {
+		result.WriteString(fmt.Sprintf("\nArchive SHA-256: %s\n", data.SHA256))
+	}
+
 	if data.Size > 1000000 {
 		result.WriteString(warning.Sprintf("The size of this module, gzipped, was %s. This seems to be extraordinarily large for an IaC module. Use a .terraformignore file to exclude files that aren't needed by the module.", data.SizeHuman))
 	}